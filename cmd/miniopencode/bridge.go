@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"miniopencode/internal/config"
+	"miniopencode/internal/proxy"
+)
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runBridge implements `miniopencode bridge`, exposing the headless command
+// set over a local WebSocket and REST API. It returns the process exit code.
+func runBridge(args []string) int {
+	fs := flag.NewFlagSet("bridge", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
+	profile := fs.String("profile", "", "config profile to apply (default: $MINIOPENCODE_PROFILE)")
+	host := fs.String("host", "", "server host")
+	port := fs.Int("port", 0, "server port")
+	serverURL := fs.String("url", "", "server base URL, e.g. https://host/prefix (overrides host/port)")
+	listen := fs.String("listen", "127.0.0.1:7778", "HTTP listen address")
+	protocol := fs.String("protocol", proxy.ProtocolJSON, "WebSocket protocol: json|jsonrpc")
+	token := fs.String("token", "", "shared secret clients must present (default: $MINIOPENCODE_BRIDGE_TOKEN)")
+	var origins stringList
+	fs.Var(&origins, "allow-origin", "allowed browser Origin pattern, repeatable (default: loopback origins)")
+	logPath := fs.String("log", "", "write logs to file (default: discard)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *token == "" {
+		*token = os.Getenv("MINIOPENCODE_BRIDGE_TOKEN")
+	}
+
+	log.SetOutput(io.Discard)
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bridge: open log: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	opts := config.Options{}
+	if *profile != "" {
+		opts.Profile = profile
+	}
+	if *host != "" {
+		opts.Host = host
+	}
+	if *port > 0 {
+		opts.Port = port
+	}
+	if *serverURL != "" {
+		opts.BaseURL = serverURL
+	}
+	cfg, _, ok := loadConfig("bridge", *configPath, opts)
+	if !ok {
+		return 1
+	}
+	cc, err := cfg.Server.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bridge: %v\n", err)
+		return 1
+	}
+
+	b, err := proxy.NewBridge(proxy.Config{
+		Host:     cfg.Server.Host,
+		Port:     fmt.Sprintf("%d", cfg.Server.Port),
+		Server:   cc,
+		Protocol: *protocol,
+	}, proxy.BridgeOptions{Token: *token, AllowedOrigins: origins})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bridge: %v\n", err)
+		return 2
+	}
+
+	srv := &http.Server{Addr: *listen, Handler: b, ReadHeaderTimeout: 10 * time.Second}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "bridge: listening on http://%s\n", *listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "bridge: %v\n", err)
+		return 1
+	}
+	return 0
+}
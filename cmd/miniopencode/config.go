@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"miniopencode/internal/config"
+)
+
+const configUsage = `usage: miniopencode config <command> [flags]
+
+commands:
+  init       write a commented default config file
+  show       print the effective config with the origin of each value
+  validate   check the config file and report problems
+  path       print the config file path in use
+`
+
+// runConfig implements `miniopencode config`. It returns the process exit
+// code.
+func runConfig(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+	switch args[0] {
+	case "init":
+		return runConfigInit(args[1:])
+	case "show":
+		return runConfigShow(args[1:])
+	case "validate":
+		return runConfigValidate(args[1:])
+	case "path":
+		return runConfigPath(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, configUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "config: unknown command %q\n", args[0])
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+}
+
+func configPathOr(path string) string {
+	if path != "" {
+		return path
+	}
+	return config.DefaultConfigPath()
+}
+
+func runConfigInit(args []string) int {
+	fs := flag.NewFlagSet("config init", flag.ContinueOnError)
+	configPath := fs.String("config", "", "file to write (default: ~/.config/miniopencode.yaml)")
+	force := fs.Bool("force", false, "overwrite an existing file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	path := configPathOr(*configPath)
+	if err := config.WriteDefault(path, *force); err != nil {
+		fmt.Fprintf(os.Stderr, "config init: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "config init: wrote %s\n", path)
+	return 0
+}
+
+func runConfigShow(args []string) int {
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
+	format := fs.String("format", "yaml", "output format: yaml|json")
+	overrides := addConfigFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "yaml" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "config show: unknown format %q (want yaml or json)\n", *format)
+		return 2
+	}
+
+	opts := overrides.options()
+	cfg, report, ok := loadConfig("config show", *configPath, opts)
+	if !ok {
+		return 1
+	}
+
+	var out []byte
+	var err error
+	if *format == "json" {
+		out, err = config.EffectiveJSON(cfg, report)
+		out = append(out, '\n')
+	} else {
+		out, err = config.EffectiveYAML(cfg, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config show: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(out)
+	return 0
+}
+
+func runConfigValidate(args []string) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
+	profile := fs.String("profile", "", "config profile to apply (default: $MINIOPENCODE_PROFILE)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	path := *configPath
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	path = configPathOr(path)
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(os.Stderr, "config validate: %v\n", err)
+		return 1
+	}
+
+	opts := config.Options{}
+	if *profile != "" {
+		opts.Profile = profile
+	}
+	_, report, err := config.LoadDetailed(path, opts)
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+	}
+	var verr *config.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		for _, issue := range verr.Issues {
+			fmt.Fprintf(os.Stderr, "error: %v\n", issue)
+		}
+		return 1
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "%s: ok\n", path)
+	return 0
+}
+
+func runConfigPath(args []string) int {
+	fs := flag.NewFlagSet("config path", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", "", "path to config file")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "config path: %v\n", err)
+		return 2
+	}
+	path := configPathOr(*configPath)
+	if path == "" {
+		fmt.Fprintln(os.Stderr, "config path: cannot determine home directory")
+		return 1
+	}
+	fmt.Fprintln(os.Stdout, path)
+	return 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"miniopencode/internal/client"
+	"miniopencode/internal/config"
+	"miniopencode/internal/export"
+)
+
+// runExport implements `miniopencode export`, writing a session's full history
+// to stdout or a file. It returns the process exit code.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
+	profile := fs.String("profile", "", "config profile to apply (default: $MINIOPENCODE_PROFILE)")
+	host := fs.String("host", "", "server host")
+	port := fs.Int("port", 0, "server port")
+	serverURL := fs.String("url", "", "server base URL, e.g. https://host/prefix (overrides host/port)")
+	sessionID := fs.String("session", "", "session ID to export (required)")
+	format := fs.String("format", "md", "output format: md|json|html")
+	output := fs.String("output", "", "write to file instead of stdout")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout for fetching the session history")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *sessionID == "" {
+		fmt.Fprintln(os.Stderr, "export: --session is required")
+		fs.Usage()
+		return 2
+	}
+	f, err := export.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 2
+	}
+
+	log.SetOutput(io.Discard)
+
+	opts := config.Options{}
+	if *profile != "" {
+		opts.Profile = profile
+	}
+	if *host != "" {
+		opts.Host = host
+	}
+	if *port > 0 {
+		opts.Port = port
+	}
+	if *serverURL != "" {
+		opts.BaseURL = serverURL
+	}
+	cfg, _, ok := loadConfig("export", *configPath, opts)
+	if !ok {
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	cc, err := cfg.Server.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	cli := client.New(cc)
+	doc, err := export.Load(ctx, cli, *sessionID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+
+	if *output != "" {
+		err = export.WriteFile(*output, doc, f)
+	} else {
+		err = export.Write(os.Stdout, doc, f)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+
+	"miniopencode/internal/config"
+)
+
+// configFlags are the flags overriding config values. The main command and
+// `config show` register the same set, so show reports what a launch with
+// the same flags would use.
+type configFlags struct {
+	fs *flag.FlagSet
+
+	profile *string
+
+	// UI flags
+	mode           *string
+	showThinking   *bool
+	showTools      *bool
+	wrap           *bool
+	inputHeight    *int
+	maxOutputLines *int
+	theme          *string
+
+	// Server flags
+	host      *string
+	port      *int
+	serverURL *string
+
+	// Session flags
+	defaultSession   *string
+	dailyMaxTokens   *int
+	dailyMaxMessages *int
+
+	// Defaults flags
+	agent      *string
+	providerID *string
+	modelID    *string
+}
+
+// addConfigFlags registers the config override flags on fs.
+func addConfigFlags(fs *flag.FlagSet) *configFlags {
+	return &configFlags{
+		fs:      fs,
+		profile: fs.String("profile", "", "config profile to apply (default: $MINIOPENCODE_PROFILE)"),
+
+		mode:           fs.String("mode", "", "UI mode: input|output|full"),
+		showThinking:   fs.Bool("show-thinking", false, "show thinking blocks"),
+		showTools:      fs.Bool("show-tools", false, "show tool calls"),
+		wrap:           fs.Bool("wrap", false, "wrap text in output"),
+		inputHeight:    fs.Int("input-height", 0, "input box height"),
+		maxOutputLines: fs.Int("max-output-lines", 0, "maximum output lines"),
+		theme:          fs.String("theme", "", "theme name"),
+
+		host:      fs.String("host", "", "server host"),
+		port:      fs.Int("port", 0, "server port"),
+		serverURL: fs.String("url", "", "server base URL, e.g. https://host/prefix (overrides host/port)"),
+
+		defaultSession:   fs.String("session", "", "default session: ID, title, or rotation daily|weekly|monthly|branch|cwd"),
+		dailyMaxTokens:   fs.Int("daily-max-tokens", 0, "daily session max tokens"),
+		dailyMaxMessages: fs.Int("daily-max-messages", 0, "daily session max messages"),
+
+		agent:      fs.String("agent", "", "default agent"),
+		providerID: fs.String("provider", "", "default provider ID"),
+		modelID:    fs.String("model", "", "default model ID"),
+	}
+}
+
+// options returns the overrides for the flags given on the command line;
+// flags left out keep the value from the lower layers.
+func (f *configFlags) options() config.Options {
+	set := make(map[string]bool)
+	f.fs.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	var opts config.Options
+	if set["profile"] {
+		opts.Profile = f.profile
+	}
+	if set["mode"] {
+		opts.Mode = f.mode
+	}
+	if set["show-thinking"] {
+		opts.ShowThinking = f.showThinking
+	}
+	if set["show-tools"] {
+		opts.ShowTools = f.showTools
+	}
+	if set["wrap"] {
+		opts.Wrap = f.wrap
+	}
+	if set["input-height"] {
+		opts.InputHeight = f.inputHeight
+	}
+	if set["max-output-lines"] {
+		opts.MaxOutputLines = f.maxOutputLines
+	}
+	if set["theme"] {
+		opts.Theme = f.theme
+	}
+	if set["host"] {
+		opts.Host = f.host
+	}
+	if set["port"] {
+		opts.Port = f.port
+	}
+	if set["url"] {
+		opts.BaseURL = f.serverURL
+	}
+	if set["session"] {
+		opts.DefaultSession = f.defaultSession
+	}
+	if set["daily-max-tokens"] {
+		opts.DailyMaxTokens = f.dailyMaxTokens
+	}
+	if set["daily-max-messages"] {
+		opts.DailyMaxMessages = f.dailyMaxMessages
+	}
+	if set["agent"] {
+		opts.Agent = f.agent
+	}
+	if set["provider"] {
+		opts.ProviderID = f.providerID
+	}
+	if set["model"] {
+		opts.ModelID = f.modelID
+	}
+	return opts
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestConfigFlagsOnlyOverrideGivenFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := addConfigFlags(fs)
+	if err := fs.Parse([]string{"--mode", "input", "--wrap=false", "--session", "weekly", "--port", "5000", "--model", "m"}); err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	opts := f.options()
+	if opts.Mode == nil || *opts.Mode != "input" || opts.DefaultSession == nil || *opts.DefaultSession != "weekly" {
+		t.Fatalf("string flags not applied: %+v", opts)
+	}
+	if opts.Wrap == nil || *opts.Wrap {
+		t.Fatalf("--wrap=false must override the config: %+v", opts.Wrap)
+	}
+	if opts.Port == nil || *opts.Port != 5000 || opts.ModelID == nil || *opts.ModelID != "m" {
+		t.Fatalf("flags not applied: %+v", opts)
+	}
+	if opts.Host != nil || opts.Theme != nil || opts.ShowThinking != nil || opts.Profile != nil {
+		t.Fatalf("flags not given must not override: %+v", opts)
+	}
+}
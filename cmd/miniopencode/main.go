@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"miniopencode/internal/config"
 	"miniopencode/internal/proxy"
@@ -17,86 +18,34 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			os.Exit(runExport(os.Args[2:]))
+		case "run":
+			os.Exit(runRun(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "bridge":
+			os.Exit(runBridge(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		}
+	}
+
 	headless := flag.Bool("headless", false, "run in headless stdin/stdout mode")
+	protocol := flag.String("protocol", proxy.ProtocolJSON, "headless protocol: json|jsonrpc")
+	framing := flag.String("framing", proxy.FramingLine, "headless message framing: line|content-length")
 	configPath := flag.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
-
-	// UI flags
-	mode := flag.String("mode", "", "UI mode: input|output|full")
-	showThinking := flag.Bool("show-thinking", false, "show thinking blocks")
-	showTools := flag.Bool("show-tools", false, "show tool calls")
-	wrap := flag.Bool("wrap", false, "wrap text in output")
-	inputHeight := flag.Int("input-height", 0, "input box height")
-	maxOutputLines := flag.Int("max-output-lines", 0, "maximum output lines")
-	theme := flag.String("theme", "", "theme name")
-
 	logPath := flag.String("log", "", "write debug logs to file (or set DEBUG=1 for default path)")
-
-	// Server flags
-	host := flag.String("host", "", "server host")
-	port := flag.Int("port", 0, "server port")
-
-	// Session flags
-	defaultSession := flag.String("session", "", "default session ID or 'daily'")
-	dailyMaxTokens := flag.Int("daily-max-tokens", 0, "daily session max tokens")
-	dailyMaxMessages := flag.Int("daily-max-messages", 0, "daily session max messages")
-
-	// Defaults flags
-	agent := flag.String("agent", "", "default agent")
-	providerID := flag.String("provider", "", "default provider ID")
-	modelID := flag.String("model", "", "default model ID")
+	overrides := addConfigFlags(flag.CommandLine)
 
 	flag.Parse()
 
-	opts := config.Options{}
-	if *mode != "" {
-		opts.Mode = mode
-	}
-	if flag.Lookup("show-thinking").Value.String() == "true" {
-		opts.ShowThinking = showThinking
-	}
-	if flag.Lookup("show-tools").Value.String() == "true" {
-		opts.ShowTools = showTools
-	}
-	if flag.Lookup("wrap").Value.String() == "true" {
-		opts.Wrap = wrap
-	}
-	if *inputHeight > 0 {
-		opts.InputHeight = inputHeight
-	}
-	if *maxOutputLines > 0 {
-		opts.MaxOutputLines = maxOutputLines
-	}
-	if *theme != "" {
-		opts.Theme = theme
-	}
-	if *host != "" {
-		opts.Host = host
-	}
-	if *port > 0 {
-		opts.Port = port
-	}
-	if *defaultSession != "" {
-		opts.DefaultSession = defaultSession
-	}
-	if *dailyMaxTokens > 0 {
-		opts.DailyMaxTokens = dailyMaxTokens
-	}
-	if *dailyMaxMessages > 0 {
-		opts.DailyMaxMessages = dailyMaxMessages
-	}
-	if *agent != "" {
-		opts.Agent = agent
-	}
-	if *providerID != "" {
-		opts.ProviderID = providerID
-	}
-	if *modelID != "" {
-		opts.ModelID = modelID
-	}
-
-	cfg, err := config.Load(*configPath, opts)
-	if err != nil {
-		log.Fatalf("load config: %v", err)
+	opts := overrides.options()
+	cfg, report, ok := loadConfig("miniopencode", *configPath, opts)
+	if !ok {
+		os.Exit(1)
 	}
 
 	var logFile *os.File
@@ -112,18 +61,59 @@ func main() {
 		logFile = f
 		defer logFile.Close()
 		log.Printf("logging enabled: %s", path)
+		logSources(report.Sources)
 	} else {
 		log.SetOutput(io.Discard)
 	}
 
 	if *headless {
-		p := proxy.NewProxy(proxy.Config{Host: cfg.Server.Host, Port: fmt.Sprintf("%d", cfg.Server.Port)})
-		p.RunHeadless()
+		cc, err := cfg.Server.ClientConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "headless: %v\n", err)
+			os.Exit(2)
+		}
+		p := proxy.NewProxy(proxy.Config{
+			Host:     cfg.Server.Host,
+			Port:     fmt.Sprintf("%d", cfg.Server.Port),
+			Server:   cc,
+			Protocol: *protocol,
+			Framing:  *framing,
+		})
+		if err := p.RunHeadless(); err != nil {
+			fmt.Fprintf(os.Stderr, "headless: %v\n", err)
+			os.Exit(2)
+		}
 		return
 	}
 
 	ctx := context.Background()
-	if err := tui.Run(ctx, cfg); err != nil {
+	if err := tui.Run(ctx, cfg, report, opts); err != nil {
 		log.Fatalf("tui: %v", err)
 	}
 }
+
+// loadConfig loads the config for the named command, printing warnings and
+// errors to stderr. It returns false if the config cannot be used.
+func loadConfig(name, path string, opts config.Options) (config.Config, config.Report, bool) {
+	cfg, report, err := config.LoadDetailed(path, opts)
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %v\n", name, w)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: load config: %v\n", name, err)
+		return cfg, report, false
+	}
+	return cfg, report, true
+}
+
+// logSources logs every config key not taken from the defaults.
+func logSources(sources config.Sources) {
+	keys := make([]string, 0, len(sources))
+	for key := range sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		log.Printf("config: %s from %s", key, sources[key])
+	}
+}
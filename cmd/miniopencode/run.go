@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"miniopencode/internal/client"
+	"miniopencode/internal/config"
+	"miniopencode/internal/export"
+	"miniopencode/internal/session"
+)
+
+// runRun implements `miniopencode run "prompt"`: it sends one prompt to the
+// resolved session and prints the answer. Piped stdin is appended to the
+// prompt as context. It returns the process exit code.
+func runRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
+	profile := fs.String("profile", "", "config profile to apply (default: $MINIOPENCODE_PROFILE)")
+	host := fs.String("host", "", "server host")
+	port := fs.Int("port", 0, "server port")
+	serverURL := fs.String("url", "", "server base URL, e.g. https://host/prefix (overrides host/port)")
+	sessionName := fs.String("session", "", "session: ID, title, or rotation daily|weekly|monthly|branch|cwd (default: from config)")
+	agent := fs.String("agent", "", "agent")
+	providerID := fs.String("provider", "", "provider ID")
+	modelID := fs.String("model", "", "model ID")
+	format := fs.String("format", "text", "output format: text|json|markdown")
+	showThinking := fs.Bool("show-thinking", false, "stream thinking to stderr (text format)")
+	showTools := fs.Bool("show-tools", false, "report tool calls on stderr (text format)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "maximum time to wait for the answer")
+	noStdin := fs.Bool("no-stdin", false, "do not read piped stdin (for callers that keep it open)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	switch *format {
+	case "text", "json", "markdown", "md":
+	default:
+		fmt.Fprintf(os.Stderr, "run: unknown format %q (want text, json or markdown)\n", *format)
+		return 2
+	}
+
+	prompt, err := buildPrompt(fs.Args(), os.Stdin, !*noStdin && stdinPiped())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run: read stdin: %v\n", err)
+		return 1
+	}
+	if prompt == "" {
+		fmt.Fprintln(os.Stderr, "run: a prompt argument or piped stdin is required")
+		fs.Usage()
+		return 2
+	}
+
+	log.SetOutput(io.Discard)
+
+	opts := config.Options{}
+	if *profile != "" {
+		opts.Profile = profile
+	}
+	if *host != "" {
+		opts.Host = host
+	}
+	if *port > 0 {
+		opts.Port = port
+	}
+	if *serverURL != "" {
+		opts.BaseURL = serverURL
+	}
+	if *sessionName != "" {
+		opts.DefaultSession = sessionName
+	}
+	if *agent != "" {
+		opts.Agent = agent
+	}
+	if *providerID != "" {
+		opts.ProviderID = providerID
+	}
+	if *modelID != "" {
+		opts.ModelID = modelID
+	}
+	cfg, _, ok := loadConfig("run", *configPath, opts)
+	if !ok {
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	cc, err := cfg.Server.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run: %v\n", err)
+		return 1
+	}
+	cli := client.New(cc)
+
+	defaultSession := cfg.Session.DefaultSession
+	if defaultSession == "" {
+		defaultSession = "miniopencode"
+	}
+	usage := session.LoadUsageCache(session.DefaultUsageCachePath())
+	resolver := session.Resolver{Client: cli, Config: cfg, Usage: usage, LockDir: session.DefaultLockDir(), Status: func(msg string) {
+		fmt.Fprintf(os.Stderr, "run: %s\n", msg)
+	}}
+	sessionID, err := resolver.Resolve(ctx, defaultSession)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run: session: %v\n", err)
+		return 1
+	}
+	if err := usage.Save(); err != nil {
+		log.Printf("run: save usage cache: %v", err)
+	}
+
+	input := client.PromptInput{
+		Parts: []client.InputPart{{Type: "text", Text: prompt}},
+		Agent: cfg.Defaults.Agent,
+	}
+	if cfg.Defaults.ProviderID != "" && cfg.Defaults.ModelID != "" {
+		input.Model = &client.ModelRef{ProviderID: cfg.Defaults.ProviderID, ModelID: cfg.Defaults.ModelID}
+	}
+
+	return printAnswer(ctx, cli, sessionID, input, answerOutput{
+		format:   *format,
+		thinking: *showThinking,
+		tools:    *showTools,
+		timeout:  *timeout,
+		stdout:   os.Stdout,
+		stderr:   os.Stderr,
+	})
+}
+
+// answerOutput says how printAnswer reports the answer.
+type answerOutput struct {
+	format          string
+	thinking, tools bool
+	timeout         time.Duration
+	stdout, stderr  io.Writer
+}
+
+// printAnswer sends input to sessionID and prints the answer in out.format.
+// It returns the process exit code: 1 if the assistant reports an error or
+// no answer arrives before ctx is done.
+func printAnswer(ctx context.Context, cli *client.Client, sessionID string, input client.PromptInput, out answerOutput) int {
+	var onPart func(client.Part)
+	var printer *streamPrinter
+	if out.format == "text" {
+		printer = &streamPrinter{out: out.stdout, errOut: out.stderr, thinking: out.thinking, tools: out.tools}
+		onPart = printer.part
+	}
+	result, err := cli.PromptStream(ctx, sessionID, input, onPart)
+	if printer != nil {
+		printer.finish()
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("no answer within %s", out.timeout)
+		}
+		fmt.Fprintf(out.stderr, "run: %v\n", err)
+		return 1
+	}
+
+	switch out.format {
+	case "json":
+		enc := json.NewEncoder(out.stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(result)
+	case "markdown", "md":
+		err = writeResultMarkdown(out.stdout, result)
+	}
+	if err != nil {
+		fmt.Fprintf(out.stderr, "run: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildPrompt joins args into the prompt and appends stdin as context when
+// it is piped or an argument is "-".
+func buildPrompt(args []string, stdin io.Reader, piped bool) (string, error) {
+	var words []string
+	read := piped
+	for _, arg := range args {
+		if arg == "-" {
+			read = true
+			continue
+		}
+		words = append(words, arg)
+	}
+	prompt := strings.TrimSpace(strings.Join(words, " "))
+	if !read {
+		return prompt, nil
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+	if extra := strings.TrimSpace(string(data)); extra != "" {
+		if prompt != "" {
+			prompt += "\n\n"
+		}
+		prompt += extra
+	}
+	return prompt, nil
+}
+
+// stdinPiped reports whether stdin is a pipe or file rather than a terminal.
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// streamPrinter writes answer text to out as it arrives, and thinking and
+// tool progress to errOut when enabled.
+type streamPrinter struct {
+	out, errOut     io.Writer
+	thinking, tools bool
+
+	printed  map[string]string
+	status   map[string]string
+	lastPart map[io.Writer]string
+	endsNL   map[io.Writer]bool
+}
+
+func (p *streamPrinter) part(part client.Part) {
+	if p.printed == nil {
+		p.printed = make(map[string]string)
+		p.status = make(map[string]string)
+		p.lastPart = make(map[io.Writer]string)
+		p.endsNL = make(map[io.Writer]bool)
+	}
+	switch part.PartType {
+	case "text":
+		p.text(p.out, part)
+	case "reasoning":
+		if p.thinking {
+			p.text(p.errOut, part)
+		}
+	case "tool":
+		if !p.tools || part.State == nil || p.status[part.ID] == part.State.Status {
+			return
+		}
+		p.status[part.ID] = part.State.Status
+		p.breakLine(p.errOut)
+		line := fmt.Sprintf("[tool] %s (%s)", part.Tool, part.State.Status)
+		if part.State.Title != "" {
+			line = fmt.Sprintf("[tool] %s: %s (%s)", part.Tool, part.State.Title, part.State.Status)
+		}
+		fmt.Fprintln(p.errOut, line)
+		p.endsNL[p.errOut] = true
+		p.lastPart[p.errOut] = part.ID
+	}
+}
+
+// text prints the not yet printed suffix of a text or reasoning part, with a
+// blank line between consecutive parts. Rewrites of already printed text
+// cannot be taken back and are ignored.
+func (p *streamPrinter) text(w io.Writer, part client.Part) {
+	prev := p.printed[part.ID]
+	if len(part.Text) <= len(prev) || !strings.HasPrefix(part.Text, prev) {
+		return
+	}
+	chunk := part.Text[len(prev):]
+	if prev == "" {
+		chunk = strings.TrimLeft(chunk, "\n")
+		if chunk == "" {
+			return
+		}
+		if last, ok := p.lastPart[w]; ok && last != part.ID {
+			p.breakLine(w)
+			io.WriteString(w, "\n")
+		}
+	}
+	io.WriteString(w, chunk)
+	p.printed[part.ID] = part.Text
+	p.lastPart[w] = part.ID
+	p.endsNL[w] = strings.HasSuffix(chunk, "\n")
+}
+
+// breakLine ends the current line on w if output was left mid-line.
+func (p *streamPrinter) breakLine(w io.Writer) {
+	if _, ok := p.lastPart[w]; ok && !p.endsNL[w] {
+		io.WriteString(w, "\n")
+		p.endsNL[w] = true
+	}
+}
+
+func (p *streamPrinter) finish() {
+	if p.lastPart == nil {
+		return
+	}
+	p.breakLine(p.out)
+	p.breakLine(p.errOut)
+}
+
+// writeResultMarkdown renders the answer with thinking and tool calls in
+// collapsed <details> blocks, followed by token usage.
+func writeResultMarkdown(w io.Writer, res *client.PromptResult) error {
+	msg := export.Message{Role: "assistant", Tokens: &res.Tokens, Cost: res.Cost}
+	if res.Reasoning != "" {
+		msg.Parts = append(msg.Parts, export.Part{Type: "reasoning", Text: res.Reasoning})
+	}
+	for _, t := range res.Tools {
+		msg.Parts = append(msg.Parts, export.Part{
+			Type:   "tool",
+			Tool:   t.Tool,
+			Status: t.State.Status,
+			Title:  t.State.Title,
+			Input:  t.State.Input,
+			Output: t.State.Output,
+			Error:  t.State.Error,
+		})
+	}
+	msg.Parts = append(msg.Parts, export.Part{Type: "text", Text: res.Text})
+
+	var buf bytes.Buffer
+	if err := export.WriteMarkdownMessage(&buf, msg); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, strings.TrimLeft(buf.String(), "\n"))
+	return err
+}
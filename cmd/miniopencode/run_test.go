@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+func TestBuildPrompt(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		stdin string
+		piped bool
+		want  string
+	}{
+		{"args only", []string{"explain", "this"}, "", false, "explain this"},
+		{"terminal stdin is not read", []string{"explain"}, "ignored", false, "explain"},
+		{"args plus piped stdin", []string{"explain this"}, "panic: boom\n", true, "explain this\n\npanic: boom"},
+		{"dash reads stdin", []string{"explain", "-"}, "log line", false, "explain\n\nlog line"},
+		{"stdin only", nil, "  what is a CRDT?\n", true, "what is a CRDT?"},
+		{"empty stdin", []string{"explain"}, "\n", true, "explain"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := buildPrompt(tc.args, strings.NewReader(tc.stdin), tc.piped)
+			if err != nil {
+				t.Fatalf("buildPrompt: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStreamPrinter(t *testing.T) {
+	var out, errOut bytes.Buffer
+	p := &streamPrinter{out: &out, errOut: &errOut, thinking: true, tools: true}
+	running := &client.ToolState{Status: "running"}
+	done := &client.ToolState{Status: "completed", Title: "ls"}
+	for _, part := range []client.Part{
+		{ID: "r1", PartType: "reasoning", Text: "think"},
+		{ID: "t1", PartType: "tool", Tool: "bash", State: running},
+		{ID: "t1", PartType: "tool", Tool: "bash", State: running},
+		{ID: "t1", PartType: "tool", Tool: "bash", State: done},
+		{ID: "p1", PartType: "text", Text: "\nHel"},
+		{ID: "p1", PartType: "text", Text: "\nHello"},
+		{ID: "p1", PartType: "text", Text: "rewritten"},
+		{ID: "p2", PartType: "text", Text: "World"},
+	} {
+		p.part(part)
+	}
+	p.finish()
+
+	if got, want := out.String(), "Hello\n\nWorld\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, want := errOut.String(), "think\n[tool] bash (running)\n[tool] bash: ls (completed)\n"; got != want {
+		t.Errorf("stderr = %q, want %q", got, want)
+	}
+}
+
+func TestStreamPrinterHidesThinkingAndTools(t *testing.T) {
+	var out, errOut bytes.Buffer
+	p := &streamPrinter{out: &out, errOut: &errOut}
+	p.part(client.Part{ID: "r1", PartType: "reasoning", Text: "think"})
+	p.part(client.Part{ID: "t1", PartType: "tool", Tool: "bash", State: &client.ToolState{Status: "running"}})
+	p.part(client.Part{ID: "p1", PartType: "text", Text: "answer"})
+	p.finish()
+
+	if out.String() != "answer\n" || errOut.Len() != 0 {
+		t.Fatalf("unexpected output: stdout=%q stderr=%q", out.String(), errOut.String())
+	}
+}
+
+func TestWriteResultMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeResultMarkdown(&buf, &client.PromptResult{
+		Text:      "The answer.",
+		Reasoning: "Let me think.",
+		Tools:     []client.ToolCall{{Tool: "bash", State: client.ToolState{Status: "completed", Title: "ls <dir>", Output: "a.go"}}},
+		Tokens:    client.Tokens{Input: 10, Output: 5},
+		Cost:      0.01,
+	})
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	text := buf.String()
+	if !strings.HasPrefix(text, "<details>\n<summary>Thinking</summary>") {
+		t.Errorf("expected thinking first without leading blank lines:\n%s", text)
+	}
+	for _, want := range []string{
+		"Let me think.",
+		"<summary>Tool: bash — ls &lt;dir&gt; (completed)</summary>",
+		"a.go",
+		"The answer.",
+		"tokens: 10 in / 5 out / 0 reasoning",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "a.go") > strings.Index(text, "The answer.") {
+		t.Errorf("answer should follow the tool calls:\n%s", text)
+	}
+}
+
+// newAnswerServer serves /event, emitting events once the prompt has been
+// posted. The user message "u1" in events takes the posted messageID.
+func newAnswerServer(t *testing.T, events ...string) *httptest.Server {
+	t.Helper()
+	posted := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			var userID string
+			select {
+			case userID = <-posted:
+			case <-r.Context().Done():
+				return
+			}
+			for _, ev := range events {
+				fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(ev, `"u1"`, `"`+userID+`"`))
+			}
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/prompt_async"):
+			var input client.PromptInput
+			json.NewDecoder(r.Body).Decode(&input)
+			posted <- input.MessageID
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPrintAnswerExitCodes(t *testing.T) {
+	userMsg := `{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"s1","role":"user"}}}`
+	assistant := `{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1"}}}`
+	text := `{"type":"message.part.updated","properties":{"part":{"id":"p1","sessionID":"s1","messageID":"a1","type":"text","text":"Hi"}}}`
+	for _, tc := range []struct {
+		name    string
+		events  []string
+		timeout time.Duration
+		code    int
+		stdout  string
+		stderr  string
+	}{
+		{
+			name:    "answer",
+			events:  []string{userMsg, assistant, text, `{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1","finish":"stop","time":{"created":1,"completed":2}}}}`},
+			timeout: 5 * time.Second,
+			stdout:  "Hi\n",
+		},
+		{
+			name:    "message error",
+			events:  []string{userMsg, assistant, text, `{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1","error":{"name":"ProviderAuthError","data":{"message":"bad key"}},"time":{"created":1,"completed":2}}}}`},
+			timeout: 5 * time.Second,
+			code:    1,
+			stdout:  "Hi\n",
+			stderr:  "run: ProviderAuthError: bad key\n",
+		},
+		{
+			name:    "timeout",
+			events:  []string{userMsg, assistant},
+			timeout: 100 * time.Millisecond,
+			code:    1,
+			stderr:  "run: no answer within 100ms\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newAnswerServer(t, tc.events...)
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			var stdout, stderr bytes.Buffer
+			code := printAnswer(ctx, client.New(client.Config{BaseURL: srv.URL}), "s1", client.PromptInput{}, answerOutput{
+				format:  "text",
+				timeout: tc.timeout,
+				stdout:  &stdout,
+				stderr:  &stderr,
+			})
+			if code != tc.code || stdout.String() != tc.stdout || stderr.String() != tc.stderr {
+				t.Fatalf("got code=%d stdout=%q stderr=%q", code, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
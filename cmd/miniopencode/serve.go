@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"miniopencode/internal/config"
+	"miniopencode/internal/proxy"
+)
+
+// runServe implements `miniopencode serve --listen ADDR`, serving the headless
+// protocol to many clients at once until interrupted. It returns the process
+// exit code.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file (default: ~/.config/miniopencode.yaml)")
+	profile := fs.String("profile", "", "config profile to apply (default: $MINIOPENCODE_PROFILE)")
+	host := fs.String("host", "", "server host")
+	port := fs.Int("port", 0, "server port")
+	serverURL := fs.String("url", "", "server base URL, e.g. https://host/prefix (overrides host/port)")
+	listen := fs.String("listen", "", "address to listen on: unix:///path/to.sock or tcp://host:port (required)")
+	protocol := fs.String("protocol", proxy.ProtocolJSON, "protocol: json|jsonrpc")
+	framing := fs.String("framing", proxy.FramingLine, "message framing: line|content-length")
+	logPath := fs.String("log", "", "write logs to file (default: stderr)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *listen == "" {
+		fmt.Fprintln(os.Stderr, "serve: --listen is required")
+		fs.Usage()
+		return 2
+	}
+
+	log.SetOutput(io.Discard)
+	if *logPath != "" {
+		f, err := os.OpenFile(*logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "serve: open log: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	opts := config.Options{}
+	if *profile != "" {
+		opts.Profile = profile
+	}
+	if *host != "" {
+		opts.Host = host
+	}
+	if *port > 0 {
+		opts.Port = port
+	}
+	if *serverURL != "" {
+		opts.BaseURL = serverURL
+	}
+	cfg, _, ok := loadConfig("serve", *configPath, opts)
+	if !ok {
+		return 1
+	}
+	cc, err := cfg.Server.ClientConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return 1
+	}
+
+	srv, err := proxy.NewServer(proxy.Config{
+		Host:     cfg.Server.Host,
+		Port:     fmt.Sprintf("%d", cfg.Server.Port),
+		Server:   cc,
+		Protocol: *protocol,
+		Framing:  *framing,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return 2
+	}
+	l, err := proxy.Listen(*listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return 1
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		l.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "serve: listening on %s\n", *listen)
+	if err := srv.Serve(l); err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
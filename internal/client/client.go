@@ -3,20 +3,38 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
-// Config holds client configuration.
+// SocketBaseURL is the base URL used for a Unix socket connection when no
+// BaseURL is given; its host only fills the Host header.
+const SocketBaseURL = "http://localhost"
+
+// Config holds client configuration. BaseURL may carry a path prefix (for a
+// server behind a reverse proxy); otherwise http://Host:Port is used.
+// Socket, if set, routes all traffic over that Unix domain socket instead of
+// TCP. Credentials and Headers are sent with every request, including the
+// SSE stream. Token (bearer) takes precedence over Username/Password (basic).
 type Config struct {
 	Host    string
 	Port    int
 	BaseURL string
+	Socket  string
 	Timeout time.Duration
+
+	Username string
+	Password string
+	Token    string
+	Headers  map[string]string
+	TLS      *tls.Config
 }
 
 // ModelRef selects provider/model.
@@ -41,13 +59,23 @@ type PromptInput struct {
 	System  string      `json:"system,omitempty"`
 	NoReply bool        `json:"noReply,omitempty"`
 	Variant string      `json:"variant,omitempty"`
+	// MessageID, if set, becomes the ID of the prompt's user message (see
+	// NewMessageID), so its answer can be told apart from concurrent ones.
+	MessageID string `json:"messageID,omitempty"`
 }
 
 // Session represents minimal session info.
 type Session struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-	Part  int    `json:"-"`
+	ID    string       `json:"id"`
+	Title string       `json:"title"`
+	Time  *SessionTime `json:"time,omitempty"`
+	Part  int          `json:"-"`
+}
+
+// SessionTime holds session timestamps in epoch milliseconds.
+type SessionTime struct {
+	Created float64 `json:"created"`
+	Updated float64 `json:"updated"`
 }
 
 // TokenUsage captures token counts on a message.
@@ -79,6 +107,9 @@ type Client struct {
 // New builds a client from config, defaulting host/port when BaseURL is empty.
 func New(cfg Config) *Client {
 	baseURL := cfg.BaseURL
+	if baseURL == "" && cfg.Socket != "" {
+		baseURL = SocketBaseURL
+	}
 	if baseURL == "" {
 		host := cfg.Host
 		if host == "" {
@@ -91,20 +122,114 @@ func New(cfg Config) *Client {
 		baseURL = fmt.Sprintf("http://%s:%d", host, port)
 	}
 
+	baseURL = strings.TrimRight(baseURL, "/")
+
 	timeout := cfg.Timeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
 	}
 
+	transport := newTransport(cfg)
 	return &Client{
 		baseURL:       baseURL,
-		http:          &http.Client{Timeout: timeout},
-		httpNoTimeout: &http.Client{Timeout: 0},
+		http:          &http.Client{Timeout: timeout, Transport: transport},
+		httpNoTimeout: &http.Client{Timeout: 0, Transport: transport},
+	}
+}
+
+// newTransport returns the round tripper applying cfg's socket, TLS settings,
+// credentials and headers, or nil for the default transport.
+func newTransport(cfg Config) http.RoundTripper {
+	var base http.RoundTripper
+	if cfg.TLS != nil || cfg.Socket != "" {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = cfg.TLS
+		if cfg.Socket != "" {
+			socket := cfg.Socket
+			t.Proxy = nil
+			t.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			}
+		}
+		base = t
+	}
+	if cfg.Username == "" && cfg.Token == "" && len(cfg.Headers) == 0 {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
 	}
+	return &authTransport{
+		base:     base,
+		username: cfg.Username,
+		password: cfg.Password,
+		token:    cfg.Token,
+		headers:  cfg.Headers,
+	}
+}
+
+// authTransport adds credentials and custom headers to every request.
+type authTransport struct {
+	base     http.RoundTripper
+	username string
+	password string
+	token    string
+	headers  map[string]string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	switch {
+	case t.token != "":
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	case t.username != "":
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// BaseURL returns the server base URL the client talks to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Health reports whether the server answers /global/health with 200 OK.
+func (c *Client) Health(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/global/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &HTTPError{StatusCode: resp.StatusCode}
+	}
+	return nil
 }
 
 // ListSessions fetches sessions.
 func (c *Client) ListSessions(ctx context.Context) ([]Session, error) {
+	raw, err := c.ListSessionsRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []Session
+	if err := json.Unmarshal(raw, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ListSessionsRaw fetches sessions as the server's JSON array, with every
+// field the server sends rather than the subset in Session.
+func (c *Client) ListSessionsRaw(ctx context.Context) (json.RawMessage, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/session", nil)
 	if err != nil {
 		return nil, err
@@ -118,11 +243,36 @@ func (c *Client) ListSessions(ctx context.Context) ([]Session, error) {
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("list sessions failed: %s", string(body))
 	}
-	var sessions []Session
-	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
 		return nil, err
 	}
-	return sessions, nil
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("list sessions: invalid JSON response")
+	}
+	return body, nil
+}
+
+// GetSession fetches a single session by ID.
+func (c *Client) GetSession(ctx context.Context, sessionID string) (Session, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/session/%s", c.baseURL, sessionID), nil)
+	if err != nil {
+		return Session{}, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Session{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return Session{}, fmt.Errorf("get session failed: %s", string(body))
+	}
+	var session Session
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return Session{}, err
+	}
+	return session, nil
 }
 
 // CreateSession creates a session with given title.
@@ -182,9 +332,13 @@ func (c *Client) SendPromptAsync(ctx context.Context, sessionID string, input Pr
 	return nil
 }
 
+// EventStream returns an SSE client for the project /event endpoint, sharing
+// the client's credentials, headers and TLS settings.
+func (c *Client) EventStream() *SSEClient {
+	return &SSEClient{url: c.baseURL + "/event", httpClient: c.httpNoTimeout}
+}
+
 // ConsumeSSE connects to /event and streams events into provided channels.
 func (c *Client) ConsumeSSE(ctx context.Context, out chan<- SSEEvent, errs chan<- error) {
-	url := c.baseURL + "/event"
-	sseClient := NewSSEClient(url)
-	sseClient.Connect(ctx, out, errs)
+	c.EventStream().Connect(ctx, out, errs)
 }
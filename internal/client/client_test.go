@@ -5,9 +5,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -21,6 +25,27 @@ func TestBaseURLFromHostPort(t *testing.T) {
 	}
 }
 
+func TestHealth(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/global/health" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL})
+	if err := c.Health(context.Background()); err != nil {
+		t.Fatalf("expected healthy: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	var httpErr *HTTPError
+	if err := c.Health(context.Background()); !errors.As(err, &httpErr) || httpErr.StatusCode != status {
+		t.Fatalf("expected HTTPError 503, got %v", err)
+	}
+}
+
 func TestListSessions(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/session" || r.Method != http.MethodGet {
@@ -144,3 +169,162 @@ loop:
 		t.Fatalf("unexpected second event: %+v", received[1])
 	}
 }
+
+func TestGetSession(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/ses1" || r.Method != http.MethodGet {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		io.WriteString(w, `{"id":"ses1","title":"t1","time":{"created":1700000000000,"updated":1700000001000}}`)
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL})
+	s, err := c.GetSession(context.Background(), "ses1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if s.Title != "t1" || s.Time == nil || s.Time.Updated != 1700000001000 {
+		t.Fatalf("unexpected session: %+v", s)
+	}
+}
+
+func TestSessionMessagesIncludesParts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/ses1/message" {
+			t.Fatalf("unexpected path: %s", r.URL.Path)
+		}
+		io.WriteString(w, `[{"info":{"id":"m1","role":"assistant"},"parts":[
+			{"id":"p1","type":"text","text":"hi"},
+			{"id":"p2","type":"tool","tool":"bash","state":{"status":"completed","input":{"command":"ls"},"output":"a.go"}}]}]`)
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL})
+	msgs, err := c.SessionMessages(context.Background(), "ses1")
+	if err != nil {
+		t.Fatalf("messages: %v", err)
+	}
+	if len(msgs) != 1 || len(msgs[0].Parts) != 2 {
+		t.Fatalf("unexpected messages: %+v", msgs)
+	}
+	tool := msgs[0].Parts[1]
+	if tool.State == nil || tool.State.Output != "a.go" || tool.State.Input["command"] != "ls" {
+		t.Fatalf("tool state not decoded: %+v", tool.State)
+	}
+}
+
+func TestRecentMessagesSendsLimitAndUnwrapsInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session/ses1/message" || r.URL.Query().Get("limit") != "2" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		io.WriteString(w, `[{"info":{"id":"m2","tokens":{"input":3,"output":4}},"parts":[]},{"id":"m3","tokens":{"input":1}}]`)
+	}))
+	defer srv.Close()
+
+	c := New(Config{BaseURL: srv.URL})
+	msgs, err := c.RecentMessages(context.Background(), "ses1", 2)
+	if err != nil {
+		t.Fatalf("messages: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].ID != "m2" || msgs[0].Tokens.Output != 4 || msgs[1].ID != "m3" || msgs[1].Tokens.Input != 1 {
+		t.Fatalf("unexpected messages: %+v", msgs)
+	}
+}
+
+func TestCredentialsAndHeadersOnRESTAndSSE(t *testing.T) {
+	type seen struct{ path, auth, tenant string }
+	requests := make(chan seen, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- seen{r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Tenant")}
+		if r.URL.Path == "/opencode/event" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {}\n\n"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"basic", Config{Username: "u", Password: "p"}, "Basic dTpw"},
+		{"bearer wins", Config{Username: "u", Password: "p", Token: "tok"}, "Bearer tok"},
+	}
+	for _, tt := range tests {
+		tt.cfg.BaseURL = srv.URL + "/opencode/"
+		tt.cfg.Headers = map[string]string{"X-Tenant": "acme"}
+		c := New(tt.cfg)
+		if err := c.Health(context.Background()); err != nil {
+			t.Fatalf("%s: health: %v", tt.name, err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		events := make(chan SSEEvent, 1)
+		go c.EventStream().Connect(ctx, events, make(chan error, 1))
+		select {
+		case <-events:
+		case <-ctx.Done():
+			t.Fatalf("%s: no event received", tt.name)
+		}
+		cancel()
+
+		for _, path := range []string{"/opencode/global/health", "/opencode/event"} {
+			got := <-requests
+			if got.path != path || got.auth != tt.want || got.tenant != "acme" {
+				t.Fatalf("%s: expected %s with %q, got %+v", tt.name, path, tt.want, got)
+			}
+		}
+	}
+}
+
+func TestUnixSocketTransport(t *testing.T) {
+	dir, err := os.MkdirTemp("", "oc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "oc.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/global/health":
+			w.WriteHeader(http.StatusOK)
+		case "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"type\":\"server.connected\"}\n\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	c := New(Config{Host: "192.0.2.1", Port: 9, Socket: sock})
+	if c.BaseURL() != SocketBaseURL {
+		t.Fatalf("unexpected base url: %s", c.BaseURL())
+	}
+	if err := c.Health(context.Background()); err != nil {
+		t.Fatalf("health over socket: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	events := make(chan SSEEvent, 1)
+	go c.EventStream().Connect(ctx, events, make(chan error, 1))
+	select {
+	case ev := <-events:
+		if !strings.Contains(string(ev.Data), "server.connected") {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	case <-ctx.Done():
+		t.Fatal("no event received over socket")
+	}
+}
@@ -21,27 +21,54 @@ type Tokens struct {
 	Reasoning int `json:"reasoning"`
 }
 
+type ToolState struct {
+	Status string         `json:"status"`
+	Title  string         `json:"title,omitempty"`
+	Input  map[string]any `json:"input,omitempty"`
+	Output string         `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
 type Part struct {
-	ID        string   `json:"id"`
-	SessionID string   `json:"sessionID"`
-	MessageID string   `json:"messageID"`
-	PartType  string   `json:"type"`
-	Text      string   `json:"text,omitempty"`
-	Tool      string   `json:"tool,omitempty"`
-	CallID    string   `json:"callID,omitempty"`
-	Time      PartTime `json:"time,omitempty"`
+	ID        string     `json:"id"`
+	SessionID string     `json:"sessionID"`
+	MessageID string     `json:"messageID"`
+	PartType  string     `json:"type"`
+	Text      string     `json:"text,omitempty"`
+	Tool      string     `json:"tool,omitempty"`
+	CallID    string     `json:"callID,omitempty"`
+	State     *ToolState `json:"state,omitempty"`
+	Time      PartTime   `json:"time,omitempty"`
+}
+
+// MessageError is the error recorded on an assistant message that failed.
+type MessageError struct {
+	Name string `json:"name"`
+	Data struct {
+		Message string `json:"message"`
+	} `json:"data"`
+}
+
+func (e *MessageError) Error() string {
+	if e.Data.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Data.Message
 }
 
 type MessageInfo struct {
-	ID         string       `json:"id"`
-	SessionID  string       `json:"sessionID"`
-	Role       string       `json:"role"`
-	ModelID    string       `json:"modelID,omitempty"`
-	ProviderID string       `json:"providerID,omitempty"`
-	Agent      string       `json:"agent,omitempty"`
-	Cost       float64      `json:"cost,omitempty"`
-	Tokens     *Tokens      `json:"tokens,omitempty"`
-	Time       *MessageTime `json:"time,omitempty"`
+	ID         string        `json:"id"`
+	SessionID  string        `json:"sessionID"`
+	Role       string        `json:"role"`
+	ParentID   string        `json:"parentID,omitempty"`
+	ModelID    string        `json:"modelID,omitempty"`
+	ProviderID string        `json:"providerID,omitempty"`
+	Agent      string        `json:"agent,omitempty"`
+	Cost       float64       `json:"cost,omitempty"`
+	Tokens     *Tokens       `json:"tokens,omitempty"`
+	Time       *MessageTime  `json:"time,omitempty"`
+	Finish     string        `json:"finish,omitempty"`
+	Error      *MessageError `json:"error,omitempty"`
 }
 
 type MessagePartUpdatedEvent struct {
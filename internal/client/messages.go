@@ -11,7 +11,17 @@ import (
 
 // ListMessages fetches session messages (minimal fields with token usage).
 func (c *Client) ListMessages(ctx context.Context, sessionID string) ([]Message, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/session/%s/message", c.baseURL, sessionID), nil)
+	return c.listMessages(ctx, fmt.Sprintf("%s/session/%s/message", c.baseURL, sessionID))
+}
+
+// RecentMessages fetches at most the limit most recent messages of a
+// session, oldest first. Servers that ignore the limit return them all.
+func (c *Client) RecentMessages(ctx context.Context, sessionID string, limit int) ([]Message, error) {
+	return c.listMessages(ctx, fmt.Sprintf("%s/session/%s/message?limit=%d", c.baseURL, sessionID, limit))
+}
+
+func (c *Client) listMessages(ctx context.Context, url string) ([]Message, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +34,49 @@ func (c *Client) ListMessages(ctx context.Context, sessionID string) ([]Message,
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("list messages failed: %s", string(body))
 	}
-	var msgs []Message
+	// Messages come either flat or, from the history endpoint, wrapped as
+	// {"info": ..., "parts": [...]}.
+	var raw []struct {
+		Message
+		Info *Message `json:"info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	msgs := make([]Message, len(raw))
+	for i, m := range raw {
+		msgs[i] = m.Message
+		if m.Info != nil {
+			msgs[i] = *m.Info
+		}
+	}
+	return msgs, nil
+}
+
+// MessageWithParts is a message together with all of its parts, as returned
+// by the session history endpoint.
+type MessageWithParts struct {
+	Info  MessageInfo `json:"info"`
+	Parts []Part      `json:"parts"`
+}
+
+// SessionMessages fetches the full history of a session, including every part
+// (text, reasoning, tool calls) of each message.
+func (c *Client) SessionMessages(ctx context.Context, sessionID string) ([]MessageWithParts, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/session/%s/message", c.baseURL, sessionID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("session messages failed: %s", string(body))
+	}
+	var msgs []MessageWithParts
 	if err := json.NewDecoder(resp.Body).Decode(&msgs); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// finishToolCalls is the finish reason of an assistant step that ended to run
+// tools; the server continues the turn with another assistant message.
+const finishToolCalls = "tool-calls"
+
+// ToolCall is the final state of a tool invoked while answering a prompt.
+type ToolCall struct {
+	PartID string    `json:"part_id"`
+	CallID string    `json:"call_id,omitempty"`
+	Tool   string    `json:"tool"`
+	State  ToolState `json:"state"`
+}
+
+// PromptResult is the assembled outcome of a prompt: the answer and reasoning
+// text of every assistant step, the tool calls made, and usage summed over
+// the steps.
+type PromptResult struct {
+	SessionID string     `json:"session_id"`
+	MessageID string     `json:"message_id"`
+	Text      string     `json:"text"`
+	Reasoning string     `json:"reasoning,omitempty"`
+	Tools     []ToolCall `json:"tools,omitempty"`
+	Tokens    Tokens     `json:"tokens"`
+	Cost      float64    `json:"cost"`
+	Finish    string     `json:"finish,omitempty"`
+}
+
+var messageIDs struct {
+	sync.Mutex
+	last    int64
+	counter int64
+}
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// NewMessageID returns a fresh message ID in the server's ascending format,
+// "msg_" followed by a millisecond timestamp and counter in hex and random
+// characters, so it sorts after the messages already in the session.
+func NewMessageID() string {
+	messageIDs.Lock()
+	now := time.Now().UnixMilli()
+	if now != messageIDs.last {
+		messageIDs.last, messageIDs.counter = now, 0
+	}
+	messageIDs.counter++
+	v := now*0x1000 + messageIDs.counter
+	messageIDs.Unlock()
+
+	random := make([]byte, 14)
+	rand.Read(random)
+	for i, b := range random {
+		random[i] = base62[int(b)%len(base62)]
+	}
+	return fmt.Sprintf("msg_%012x%s", v&0xffffffffffff, random)
+}
+
+// PromptWait sends input to the session and follows its events until the
+// assistant has finished answering, returning the assembled result. The
+// answer is matched to the prompt by its user message ID, assigned here
+// unless input.MessageID is set. The
+// event stream is connected before the prompt is sent so no event is missed.
+// Cancel ctx (or give it a deadline) to stop waiting. If the assistant
+// reports an error, the partial result is returned with a *MessageError.
+func (c *Client) PromptWait(ctx context.Context, sessionID string, input PromptInput) (*PromptResult, error) {
+	return c.PromptStream(ctx, sessionID, input, nil)
+}
+
+// PromptStream is PromptWait that also calls onPart, if non-nil, with the
+// full current state of every answer part as it changes.
+func (c *Client) PromptStream(ctx context.Context, sessionID string, input PromptInput, onPart func(Part)) (*PromptResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	connected := make(chan struct{})
+	stream := c.EventStream()
+	stream.OnConnect = func() { close(connected) }
+
+	events := make(chan SSEEvent, 32)
+	errs := make(chan error, 1)
+	go func() {
+		stream.Connect(ctx, events, errs)
+		close(events)
+	}()
+
+	select {
+	case <-connected:
+	case err := <-errs:
+		return nil, fmt.Errorf("connect event stream: %w", err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if input.MessageID == "" {
+		input.MessageID = NewMessageID()
+	}
+	if err := c.SendPromptAsync(ctx, sessionID, input); err != nil {
+		return nil, err
+	}
+
+	w := newPromptWaiter(sessionID, input.MessageID)
+	w.onPart = onPart
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				select {
+				case err := <-errs:
+					return w.result(), fmt.Errorf("event stream: %w", err)
+				default:
+				}
+				if ctx.Err() != nil {
+					return w.result(), ctx.Err()
+				}
+				return w.result(), fmt.Errorf("event stream closed before the answer completed")
+			}
+			parsed, err := ParseEvent(ev)
+			if err != nil {
+				continue
+			}
+			if done, err := w.handle(parsed); done {
+				return w.result(), err
+			}
+		case <-ctx.Done():
+			return w.result(), ctx.Err()
+		}
+	}
+}
+
+// promptWaiter assembles a PromptResult from the events of one prompt.
+type promptWaiter struct {
+	sessionID string
+	userID    string // the prompt's user message
+	userSeen  bool
+	messages  map[string]bool // assistant messages answering the prompt
+	order     []string        // part IDs in order of first appearance
+	parts     map[string]Part
+	usage     map[string]MessageInfo
+	lastID    string
+	finish    string
+	onPart    func(Part)
+}
+
+func newPromptWaiter(sessionID, userID string) *promptWaiter {
+	return &promptWaiter{
+		sessionID: sessionID,
+		userID:    userID,
+		messages:  make(map[string]bool),
+		parts:     make(map[string]Part),
+		usage:     make(map[string]MessageInfo),
+	}
+}
+
+// handle applies one event and reports whether the answer is complete, with
+// the assistant's error if it failed.
+func (w *promptWaiter) handle(parsed any) (bool, error) {
+	switch e := parsed.(type) {
+	case *MessageUpdatedEvent:
+		info := e.Properties.Info
+		if info.SessionID != w.sessionID {
+			return false, nil
+		}
+		switch info.Role {
+		case "user":
+			if info.ID == w.userID {
+				w.userSeen = true
+			}
+			return false, nil
+		case "assistant":
+			if !w.answers(info) {
+				return false, nil
+			}
+			w.messages[info.ID] = true
+			w.usage[info.ID] = info
+			w.lastID = info.ID
+			if info.Error != nil {
+				return true, info.Error
+			}
+			if info.IsComplete() && info.Finish != finishToolCalls {
+				w.finish = info.Finish
+				return true, nil
+			}
+		}
+	case *MessagePartUpdatedEvent:
+		part := e.Properties.Part
+		if part.SessionID != w.sessionID || !w.messages[part.MessageID] {
+			return false, nil
+		}
+		if _, seen := w.parts[part.ID]; !seen {
+			w.order = append(w.order, part.ID)
+		}
+		w.parts[part.ID] = part
+		if w.onPart != nil {
+			w.onPart(part)
+		}
+	}
+	return false, nil
+}
+
+// answers reports whether an assistant message belongs to this prompt: it
+// must reply to the prompt's user message when the server says which one,
+// and otherwise must not have been completed before the prompt was seen.
+func (w *promptWaiter) answers(info MessageInfo) bool {
+	if w.messages[info.ID] {
+		return true
+	}
+	if info.ParentID != "" {
+		return info.ParentID == w.userID
+	}
+	return w.userSeen || !info.IsComplete()
+}
+
+func (w *promptWaiter) result() *PromptResult {
+	res := &PromptResult{SessionID: w.sessionID, MessageID: w.lastID, Finish: w.finish}
+	var text, reasoning []string
+	for _, id := range w.order {
+		part := w.parts[id]
+		switch part.PartType {
+		case "text":
+			if strings.TrimSpace(part.Text) != "" {
+				text = append(text, part.Text)
+			}
+		case "reasoning":
+			if strings.TrimSpace(part.Text) != "" {
+				reasoning = append(reasoning, part.Text)
+			}
+		case "tool":
+			call := ToolCall{PartID: part.ID, CallID: part.CallID, Tool: part.Tool}
+			if part.State != nil {
+				call.State = *part.State
+			}
+			res.Tools = append(res.Tools, call)
+		}
+	}
+	res.Text = strings.Join(text, "\n\n")
+	res.Reasoning = strings.Join(reasoning, "\n\n")
+	for _, info := range w.usage {
+		if info.Tokens != nil {
+			res.Tokens.Input += info.Tokens.Input
+			res.Tokens.Output += info.Tokens.Output
+			res.Tokens.Reasoning += info.Tokens.Reasoning
+		}
+		res.Cost += info.Cost
+	}
+	return res
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPromptServer serves /event, emitting events only after the prompt has
+// been posted, as the real server does. The user message "u1" in events
+// takes the messageID of the posted prompt.
+func newPromptServer(t *testing.T, events []string) *httptest.Server {
+	t.Helper()
+	posted := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/event":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			var userID string
+			select {
+			case userID = <-posted:
+			case <-r.Context().Done():
+				return
+			}
+			for _, ev := range events {
+				fmt.Fprintf(w, "data: %s\n\n", strings.ReplaceAll(ev, `"u1"`, `"`+userID+`"`))
+			}
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/prompt_async"):
+			var input PromptInput
+			json.NewDecoder(r.Body).Decode(&input)
+			select {
+			case posted <- input.MessageID:
+			default:
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPromptWaitAssemblesMultiStepAnswer(t *testing.T) {
+	srv := newPromptServer(t, []string{
+		`{"type":"message.updated","properties":{"info":{"id":"old","sessionID":"s1","role":"assistant","parentID":"u0"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"s1","role":"user"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"other","sessionID":"s2","role":"assistant"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"x","sessionID":"s2","messageID":"other","type":"text","text":"noise"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"r1","sessionID":"s1","messageID":"a1","type":"reasoning","text":"think"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"t1","sessionID":"s1","messageID":"a1","type":"tool","tool":"bash","callID":"c1","state":{"status":"running"}}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"t1","sessionID":"s1","messageID":"a1","type":"tool","tool":"bash","callID":"c1","state":{"status":"completed","output":"ok"}}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1","finish":"tool-calls","cost":0.1,"tokens":{"input":10,"output":2,"reasoning":1},"time":{"created":1,"completed":2}}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a2","sessionID":"s1","role":"assistant","parentID":"u1"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"p1","sessionID":"s1","messageID":"a2","type":"text","text":"Hel"},"delta":"Hel"}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"p1","sessionID":"s1","messageID":"a2","type":"text","text":"Hello"},"delta":"lo"}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"old-part","sessionID":"s1","messageID":"old","type":"text","text":"stale"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a2","sessionID":"s1","role":"assistant","parentID":"u1","finish":"stop","cost":0.2,"tokens":{"input":20,"output":5,"reasoning":0},"time":{"created":3,"completed":4}}}}`,
+	})
+
+	c := New(Config{BaseURL: srv.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := c.PromptWait(ctx, "s1", PromptInput{Parts: []InputPart{{Type: "text", Text: "hi"}}})
+	if err != nil {
+		t.Fatalf("PromptWait: %v", err)
+	}
+	if res.Text != "Hello" || res.Reasoning != "think" || res.MessageID != "a2" || res.Finish != "stop" {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if len(res.Tools) != 1 || res.Tools[0].State.Status != "completed" || res.Tools[0].State.Output != "ok" {
+		t.Fatalf("unexpected tools: %+v", res.Tools)
+	}
+	if res.Tokens != (Tokens{Input: 30, Output: 7, Reasoning: 1}) || res.Cost < 0.29 || res.Cost > 0.31 {
+		t.Fatalf("usage should be summed over steps: %+v cost=%v", res.Tokens, res.Cost)
+	}
+}
+
+func TestPromptWaitReturnsAssistantError(t *testing.T) {
+	srv := newPromptServer(t, []string{
+		`{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"s1","role":"user"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"p1","sessionID":"s1","messageID":"a1","type":"text","text":"partial"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1","error":{"name":"ProviderAuthError","data":{"message":"bad key"}},"time":{"created":1,"completed":2}}}}`,
+	})
+
+	c := New(Config{BaseURL: srv.URL})
+	res, err := c.PromptWait(context.Background(), "s1", PromptInput{})
+	var msgErr *MessageError
+	if !errors.As(err, &msgErr) || msgErr.Error() != "ProviderAuthError: bad key" {
+		t.Fatalf("expected MessageError, got %v", err)
+	}
+	if res == nil || res.Text != "partial" {
+		t.Fatalf("partial result should be returned: %+v", res)
+	}
+}
+
+func TestPromptWaitTimesOut(t *testing.T) {
+	srv := newPromptServer(t, []string{
+		`{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"s1","role":"user"}}}`,
+	})
+
+	c := New(Config{BaseURL: srv.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.PromptWait(ctx, "s1", PromptInput{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestPromptWaitIgnoresConcurrentTurns(t *testing.T) {
+	srv := newPromptServer(t, []string{
+		// Another client's prompt on the same session, answered first.
+		`{"type":"message.updated","properties":{"info":{"id":"u-other","sessionID":"s1","role":"user"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a-other","sessionID":"s1","role":"assistant","parentID":"u-other"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"p0","sessionID":"s1","messageID":"a-other","type":"text","text":"not mine"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a-other","sessionID":"s1","role":"assistant","parentID":"u-other","finish":"stop","time":{"created":1,"completed":2}}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"s1","role":"user"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1"}}}`,
+		`{"type":"message.part.updated","properties":{"part":{"id":"p1","sessionID":"s1","messageID":"a1","type":"text","text":"mine"}}}`,
+		`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","parentID":"u1","finish":"stop","time":{"created":3,"completed":4}}}}`,
+	})
+
+	c := New(Config{BaseURL: srv.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	res, err := c.PromptWait(ctx, "s1", PromptInput{})
+	if err != nil {
+		t.Fatalf("PromptWait: %v", err)
+	}
+	if res.Text != "mine" || res.MessageID != "a1" {
+		t.Fatalf("answer of another turn was returned: %+v", res)
+	}
+}
+
+func TestNewMessageIDAscends(t *testing.T) {
+	prev := NewMessageID()
+	for range 100 {
+		id := NewMessageID()
+		if !strings.HasPrefix(id, "msg_") || len(id) != len(prev) || id[:16] <= prev[:16] {
+			t.Fatalf("IDs must share the format and ascend: %q then %q", prev, id)
+		}
+		prev = id
+	}
+}
@@ -2,15 +2,25 @@ package client
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/tmaxmax/go-sse"
 )
 
+// MaxEventSize bounds a single SSE event. Tool output and diffs can run to
+// several megabytes, and an oversized event ends the stream for everyone
+// sharing it, so this is generous.
+const MaxEventSize = 32 << 20
+
 type SSEClient struct {
 	url        string
 	httpClient *http.Client
+
+	// OnConnect, if set, is called once the stream has been accepted by the
+	// server, before any event is delivered.
+	OnConnect func()
 }
 
 func NewSSEClient(url string) *SSEClient {
@@ -48,7 +58,11 @@ func (c *SSEClient) Connect(ctx context.Context, out chan<- SSEEvent, errs chan<
 		return
 	}
 
-	for ev, err := range sse.Read(resp.Body, nil) {
+	if c.OnConnect != nil {
+		c.OnConnect()
+	}
+
+	for ev, err := range sse.Read(resp.Body, &sse.ReadConfig{MaxEventSize: MaxEventSize}) {
 		if err != nil {
 			if ctx.Err() == nil {
 				log.Printf("sse: read error: %v", err)
@@ -64,9 +78,10 @@ func (c *SSEClient) Connect(ctx context.Context, out chan<- SSEEvent, errs chan<
 		}
 		log.Printf("sse: event=%q size=%d preview=%s", ev.Type, len(data), preview)
 
-		out <- SSEEvent{
-			Event: ev.Type,
-			Data:  []byte(data),
+		select {
+		case out <- SSEEvent{Event: ev.Type, Data: []byte(data)}:
+		case <-ctx.Done():
+			return
 		}
 	}
 
@@ -78,5 +93,5 @@ type HTTPError struct {
 }
 
 func (e *HTTPError) Error() string {
-	return "unexpected HTTP status"
+	return fmt.Sprintf("unexpected HTTP status %d", e.StatusCode)
 }
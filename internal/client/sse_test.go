@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -105,6 +106,37 @@ func TestSSEClient_Connect_MultiLineData(t *testing.T) {
 	}
 }
 
+func TestSSEClient_Connect_LargeEvent(t *testing.T) {
+	payload := strings.Repeat("x", 3<<20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("data: " + payload + "\n\n"))
+		w.Write([]byte("data: after\n\n"))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events := make(chan SSEEvent, 10)
+	errs := make(chan error, 1)
+	go NewSSEClient(server.URL).Connect(ctx, events, errs)
+
+	for _, want := range []string{payload, "after"} {
+		select {
+		case ev := <-events:
+			if string(ev.Data) != want {
+				t.Fatalf("expected %d bytes, got %d", len(want), len(ev.Data))
+			}
+		case err := <-errs:
+			t.Fatalf("unexpected error: %v", err)
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for event")
+		}
+	}
+}
+
 func TestSSEClient_Connect_ContextCancellation(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/event-stream")
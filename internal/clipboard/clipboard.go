@@ -0,0 +1,47 @@
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Local clipboard hooks, replaced in tests so they never touch the real
+// system clipboard.
+var (
+	localSupported = func() bool { return !clipboard.Unsupported }
+	writeLocal     = clipboard.WriteAll
+)
+
+// Copy places text on the clipboard. It always writes an OSC 52 escape
+// sequence to w, which reaches the local terminal even over SSH, wrapped for
+// tmux or screen when running inside one. When a local clipboard tool
+// (pbcopy, xclip, xsel, wl-copy, ...) is available the text is written there
+// as well, covering terminals that ignore OSC 52.
+func Copy(w io.Writer, text string) error {
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	_, oscErr := seq.WriteTo(w)
+
+	if !localSupported() {
+		if oscErr != nil {
+			return fmt.Errorf("osc52: %w", oscErr)
+		}
+		return nil
+	}
+	localErr := writeLocal(text)
+	if oscErr != nil && localErr != nil {
+		return errors.Join(fmt.Errorf("osc52: %w", oscErr), fmt.Errorf("local clipboard: %w", localErr))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("closed") }
+
+func stubLocal(t *testing.T, supported bool, err error) *[]string {
+	t.Helper()
+	var written []string
+	prevSupported, prevWrite := localSupported, writeLocal
+	localSupported = func() bool { return supported }
+	writeLocal = func(text string) error {
+		written = append(written, text)
+		return err
+	}
+	t.Cleanup(func() { localSupported, writeLocal = prevSupported, prevWrite })
+	return &written
+}
+
+func TestCopyWritesOSC52(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+	written := stubLocal(t, false, nil)
+
+	var buf bytes.Buffer
+	if err := Copy(&buf, "hello"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	want := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte("hello")) + "\x07"
+	if buf.String() != want {
+		t.Fatalf("unexpected sequence: %q", buf.String())
+	}
+	if len(*written) != 0 {
+		t.Fatalf("local clipboard should not be used when unsupported")
+	}
+}
+
+func TestCopyWrapsForTmux(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	stubLocal(t, false, nil)
+
+	var buf bytes.Buffer
+	if err := Copy(&buf, "hello"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "\x1bPtmux;") {
+		t.Fatalf("expected tmux passthrough, got %q", buf.String())
+	}
+}
+
+func TestCopyFallsBackToLocalClipboard(t *testing.T) {
+	t.Setenv("TMUX", "")
+	written := stubLocal(t, true, nil)
+
+	if err := Copy(failingWriter{}, "fallback"); err != nil {
+		t.Fatalf("copy should succeed via local clipboard: %v", err)
+	}
+	if len(*written) != 1 || (*written)[0] != "fallback" {
+		t.Fatalf("expected local clipboard write, got %v", *written)
+	}
+}
+
+func TestCopyFailsWhenNothingWorks(t *testing.T) {
+	t.Setenv("TMUX", "")
+	stubLocal(t, true, errors.New("no display"))
+
+	if err := Copy(failingWriter{}, "x"); err == nil {
+		t.Fatalf("expected error when both OSC 52 and local clipboard fail")
+	}
+}
@@ -2,23 +2,58 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ProfileEnv names the environment variable selecting a profile when
+// --profile is not given.
+const ProfileEnv = "MINIOPENCODE_PROFILE"
+
 type Config struct {
-	Server   ServerConfig
-	Session  SessionConfig
-	Defaults DefaultsConfig
-	UI       UIConfig
-	Theme    ThemeConfig
+	// Profile is the name of the applied profile, empty for none.
+	Profile  string         `yaml:"-"`
+	Server   ServerConfig   `yaml:"server"`
+	Session  SessionConfig  `yaml:"session"`
+	Defaults DefaultsConfig `yaml:"defaults"`
+	UI       UIConfig       `yaml:"ui"`
+	Theme    ThemeConfig    `yaml:"theme"`
+	// Keys rebinds TUI actions (see KeyActions) to lists of keys.
+	Keys map[string][]string `yaml:"keys"`
 }
 
 type ServerConfig struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+	// BaseURL, when set, replaces http://host:port. It may use https and
+	// carry a path prefix, e.g. https://example.com/opencode.
+	BaseURL string `yaml:"base_url"`
+	// Socket, when set, connects over this Unix domain socket instead of TCP.
+	Socket  string            `yaml:"socket"`
+	Auth    AuthConfig        `yaml:"auth"`
+	Headers map[string]string `yaml:"headers"`
+	TLS     TLSConfig         `yaml:"tls"`
+}
+
+// AuthConfig holds credentials for the opencode server. Token is sent as a
+// bearer token and takes precedence over Username/Password (basic auth).
+type AuthConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	Token    string `yaml:"token"`
+}
+
+// TLSConfig holds PEM files for https connections: CAFile is an additional
+// CA bundle, CertFile/KeyFile a client certificate.
+type TLSConfig struct {
+	CAFile   string `yaml:"ca_file"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
 }
 
 type SessionConfig struct {
@@ -26,6 +61,16 @@ type SessionConfig struct {
 	DailyTitleFormat string `yaml:"daily_title_format"`
 	DailyMaxTokens   int    `yaml:"daily_max_tokens"`
 	DailyMaxMessages int    `yaml:"daily_max_messages"`
+	// Match selects how a non-ID default_session finds an existing session
+	// by title: exact, prefix or fuzzy.
+	Match string `yaml:"match"`
+	// TitleFormats overrides the title format of a rotation (see Rotations)
+	// selected by default_session; daily falls back to daily_title_format.
+	TitleFormats map[string]string `yaml:"title_formats"`
+	// CarrySummary lists the rotations that summarize the previous part and
+	// seed the next one with the summary when they roll over. Off by
+	// default: summarizing costs a model call on the full session.
+	CarrySummary []string `yaml:"carry_summary"`
 }
 
 type DefaultsConfig struct {
@@ -55,8 +100,10 @@ type ThemeConfig struct {
 }
 
 type Options struct {
+	Profile          *string
 	Host             *string
 	Port             *int
+	BaseURL          *string
 	DefaultSession   *string
 	DailyMaxTokens   *int
 	DailyMaxMessages *int
@@ -80,6 +127,7 @@ func Default() Config {
 			DailyTitleFormat: "2006-01-02-daily-%d",
 			DailyMaxTokens:   250000,
 			DailyMaxMessages: 4000,
+			Match:            "exact",
 		},
 		Defaults: DefaultsConfig{},
 		UI: UIConfig{
@@ -111,71 +159,226 @@ func DefaultConfigPath() string {
 	return filepath.Join(home, ".config", "miniopencode.yaml")
 }
 
+// Load merges defaults, the YAML file at path (or DefaultConfigPath), the
+// selected profile, the project overlay found from the working directory,
+// the environment (see EnvName) and opts, in increasing order of
+// precedence. The profile comes from opts.Profile, then
+// $MINIOPENCODE_PROFILE. Invalid values yield a *ValidationError.
 func Load(path string, opts Options) (Config, error) {
+	cfg, _, err := LoadDetailed(path, opts)
+	return cfg, err
+}
+
+// Report describes how LoadDetailed arrived at a config.
+type Report struct {
+	// Path is the config file consulted, whether or not it exists.
+	Path string
+	// ProjectPath is the project overlay applied, if any (see
+	// FindProjectConfig).
+	ProjectPath string
+	// Sources records where each value came from.
+	Sources Sources
+	// Warnings lists non-fatal problems such as unknown keys.
+	Warnings []Issue
+}
+
+// LoadDetailed is Load, additionally reporting where each value came from
+// and any warnings.
+func LoadDetailed(path string, opts Options) (Config, Report, error) {
 	cfg := Default()
 	if path == "" {
 		path = DefaultConfigPath()
 	}
+	report := Report{Path: path, Sources: Sources{}}
+	profile := os.Getenv(ProfileEnv)
+	if opts.Profile != nil {
+		profile = *opts.Profile
+	}
+	var lines, projectLines map[string]int
 	data, err := os.ReadFile(path)
 	if err == nil {
-		cfgFromFile, err := parseYAML(data)
+		cfgFromFile, err := parseYAML(data, profile)
 		if err != nil {
-			return cfg, err
+			return cfg, report, fmt.Errorf("%s: %w", path, err)
 		}
 		cfg = cfgFromFile
+		report.Sources.markYAML(data, profile)
+		lines, report.Warnings = yamlLines(path, data)
 	} else if !errors.Is(err, os.ErrNotExist) {
-		return cfg, err
+		return cfg, report, err
+	} else if profile != "" {
+		return cfg, report, fmt.Errorf("unknown profile %q (no config file at %s)", profile, path)
+	}
+
+	if dir, err := os.Getwd(); err == nil {
+		if project, ok := FindProjectConfig(dir); ok && !sameFile(project, path) {
+			data, err := os.ReadFile(project)
+			if err != nil {
+				return cfg, report, err
+			}
+			warnings, err := applyProjectYAML(&cfg, project, data)
+			if err != nil {
+				return cfg, report, fmt.Errorf("%s: %w", project, err)
+			}
+			report.ProjectPath = project
+			report.Sources.markProject(data)
+			var unknown []Issue
+			projectLines, unknown = yamlLines(project, data)
+			report.Warnings = append(report.Warnings, unknown...)
+			report.Warnings = append(report.Warnings, warnings...)
+		}
+	}
+
+	env, err := envOptions(os.Getenv)
+	if err != nil {
+		return cfg, report, err
+	}
+	// Naming a server through the environment or flags starts from the
+	// default server settings, as a profile naming one does (see parseYAML).
+	if env.setsAddress() || opts.setsAddress() {
+		cfg.Server = Default().Server
+		report.Sources.resetServer()
 	}
+	cfg = applyOptions(cfg, env)
+	report.Sources.markOptions(env, SourceEnv)
 	cfg = applyOptions(cfg, opts)
-	return cfg, nil
+	report.Sources.markOptions(opts, SourceFlag)
+
+	locate := func(key string, src Source) (string, int) {
+		switch src {
+		case SourceFile:
+			return path, lines[key]
+		case SourceProfile:
+			return path, lines["profiles."+profile+"."+key]
+		case SourceProject:
+			return report.ProjectPath, projectLines[key]
+		}
+		return "", 0
+	}
+	if err := validate(cfg, report.Sources, locate); err != nil {
+		return cfg, report, err
+	}
+	return cfg, report, nil
 }
 
 type yamlConfig struct {
-	Server *struct {
-		Host *string `yaml:"host"`
-		Port *int    `yaml:"port"`
-	} `yaml:"server"`
-	Session *struct {
-		DefaultSession   *string `yaml:"default_session"`
-		DailyTitleFormat *string `yaml:"daily_title_format"`
-		DailyMaxTokens   *int    `yaml:"daily_max_tokens"`
-		DailyMaxMessages *int    `yaml:"daily_max_messages"`
-	} `yaml:"session"`
-	Defaults *struct {
-		Agent      *string `yaml:"agent"`
-		ProviderID *string `yaml:"provider_id"`
-		ModelID    *string `yaml:"model_id"`
-	} `yaml:"defaults"`
-	UI *struct {
-		Mode           *string `yaml:"mode"`
-		ShowThinking   *bool   `yaml:"show_thinking"`
-		ShowTools      *bool   `yaml:"show_tools"`
-		Wrap           *bool   `yaml:"wrap"`
-		InputHeight    *int    `yaml:"input_height"`
-		MaxOutputLines *int    `yaml:"max_output_lines"`
-		Theme          *string `yaml:"theme"`
-	} `yaml:"ui"`
-	Theme *struct {
-		BorderStyle       *string `yaml:"border_style"`
-		OutputBorderColor *string `yaml:"output_border_color"`
-		InputBorderColor  *string `yaml:"input_border_color"`
-		StatusColor       *string `yaml:"status_color"`
-		ThinkingColor     *string `yaml:"thinking_color"`
-		ToolColor         *string `yaml:"tool_color"`
-		AnswerColor       *string `yaml:"answer_color"`
-	} `yaml:"theme"`
+	Server   *yamlServer            `yaml:"server"`
+	Session  *yamlSession           `yaml:"session"`
+	Defaults *yamlDefaults          `yaml:"defaults"`
+	UI       *yamlUI                `yaml:"ui"`
+	Theme    *yamlTheme             `yaml:"theme"`
+	Keys     map[string][]string    `yaml:"keys"`
+	Profiles map[string]yamlProfile `yaml:"profiles"`
+}
+
+// yamlProfile is one entry under profiles:, applied on top of the base
+// sections when selected.
+type yamlProfile struct {
+	Server   *yamlServer   `yaml:"server"`
+	Session  *yamlSession  `yaml:"session"`
+	Defaults *yamlDefaults `yaml:"defaults"`
+	Theme    *yamlTheme    `yaml:"theme"`
+}
+
+type yamlServer struct {
+	Host    *string           `yaml:"host"`
+	Port    *int              `yaml:"port"`
+	BaseURL *string           `yaml:"base_url"`
+	Socket  *string           `yaml:"socket"`
+	Headers map[string]string `yaml:"headers"`
+	Auth    *struct {
+		Username *string `yaml:"username"`
+		Password *string `yaml:"password"`
+		Token    *string `yaml:"token"`
+	} `yaml:"auth"`
+	TLS *struct {
+		CAFile   *string `yaml:"ca_file"`
+		CertFile *string `yaml:"cert_file"`
+		KeyFile  *string `yaml:"key_file"`
+	} `yaml:"tls"`
+}
+
+type yamlSession struct {
+	DefaultSession   *string           `yaml:"default_session"`
+	DailyTitleFormat *string           `yaml:"daily_title_format"`
+	DailyMaxTokens   *int              `yaml:"daily_max_tokens"`
+	DailyMaxMessages *int              `yaml:"daily_max_messages"`
+	Match            *string           `yaml:"match"`
+	TitleFormats     map[string]string `yaml:"title_formats"`
+	CarrySummary     *[]string         `yaml:"carry_summary"`
+}
+
+type yamlDefaults struct {
+	Agent      *string `yaml:"agent"`
+	ProviderID *string `yaml:"provider_id"`
+	ModelID    *string `yaml:"model_id"`
 }
 
-func parseYAML(data []byte) (Config, error) {
+type yamlUI struct {
+	Mode           *string `yaml:"mode"`
+	ShowThinking   *bool   `yaml:"show_thinking"`
+	ShowTools      *bool   `yaml:"show_tools"`
+	Wrap           *bool   `yaml:"wrap"`
+	InputHeight    *int    `yaml:"input_height"`
+	MaxOutputLines *int    `yaml:"max_output_lines"`
+	Theme          *string `yaml:"theme"`
+}
+
+type yamlTheme struct {
+	BorderStyle       *string `yaml:"border_style"`
+	OutputBorderColor *string `yaml:"output_border_color"`
+	InputBorderColor  *string `yaml:"input_border_color"`
+	StatusColor       *string `yaml:"status_color"`
+	ThinkingColor     *string `yaml:"thinking_color"`
+	ToolColor         *string `yaml:"tool_color"`
+	AnswerColor       *string `yaml:"answer_color"`
+}
+
+// parseYAML builds a config from the file contents, applying the named
+// profile (if any) on top of the base sections.
+func parseYAML(data []byte, profile string) (Config, error) {
 	var y yamlConfig
 	if err := yaml.Unmarshal(data, &y); err != nil {
 		return Config{}, err
 	}
 	cfg := Default()
 	applyYAML(&cfg, y)
+	applyYAMLKeys(&cfg, y.Keys)
+	if profile != "" {
+		p, ok := y.Profiles[profile]
+		if !ok {
+			return Config{}, fmt.Errorf("unknown profile %q%s", profile, profileHint(y.Profiles))
+		}
+		// A profile naming another server starts from the default server
+		// settings: the base address, credentials, headers and TLS files
+		// belong to the base server and must not be sent to this one.
+		if p.Server.setsAddress() {
+			cfg.Server = Default().Server
+		}
+		applyYAML(&cfg, yamlConfig{Server: p.Server, Session: p.Session, Defaults: p.Defaults, Theme: p.Theme})
+		cfg.Profile = profile
+	}
 	return cfg, nil
 }
 
+// setsAddress reports whether s names a server: host, port, base_url or
+// socket.
+func (s *yamlServer) setsAddress() bool {
+	return s != nil && (s.Host != nil || s.Port != nil || s.BaseURL != nil || s.Socket != nil)
+}
+
+func profileHint(profiles map[string]yamlProfile) string {
+	if len(profiles) == 0 {
+		return " (no profiles defined)"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return " (available: " + strings.Join(names, ", ") + ")"
+}
+
 func applyYAML(cfg *Config, y yamlConfig) {
 	if y.Server != nil {
 		if y.Server.Host != nil {
@@ -184,6 +387,37 @@ func applyYAML(cfg *Config, y yamlConfig) {
 		if y.Server.Port != nil {
 			cfg.Server.Port = *y.Server.Port
 		}
+		if y.Server.BaseURL != nil {
+			cfg.Server.BaseURL = *y.Server.BaseURL
+		}
+		if y.Server.Socket != nil {
+			cfg.Server.Socket = *y.Server.Socket
+		}
+		if y.Server.Headers != nil {
+			cfg.Server.Headers = y.Server.Headers
+		}
+		if a := y.Server.Auth; a != nil {
+			if a.Username != nil {
+				cfg.Server.Auth.Username = *a.Username
+			}
+			if a.Password != nil {
+				cfg.Server.Auth.Password = *a.Password
+			}
+			if a.Token != nil {
+				cfg.Server.Auth.Token = *a.Token
+			}
+		}
+		if t := y.Server.TLS; t != nil {
+			if t.CAFile != nil {
+				cfg.Server.TLS.CAFile = *t.CAFile
+			}
+			if t.CertFile != nil {
+				cfg.Server.TLS.CertFile = *t.CertFile
+			}
+			if t.KeyFile != nil {
+				cfg.Server.TLS.KeyFile = *t.KeyFile
+			}
+		}
 	}
 	if y.Session != nil {
 		if y.Session.DefaultSession != nil {
@@ -198,6 +432,22 @@ func applyYAML(cfg *Config, y yamlConfig) {
 		if y.Session.DailyMaxMessages != nil {
 			cfg.Session.DailyMaxMessages = *y.Session.DailyMaxMessages
 		}
+		if y.Session.Match != nil {
+			cfg.Session.Match = *y.Session.Match
+		}
+		if y.Session.CarrySummary != nil {
+			cfg.Session.CarrySummary = *y.Session.CarrySummary
+		}
+		if len(y.Session.TitleFormats) > 0 {
+			merged := make(map[string]string, len(cfg.Session.TitleFormats)+len(y.Session.TitleFormats))
+			for rot, f := range cfg.Session.TitleFormats {
+				merged[rot] = f
+			}
+			for rot, f := range y.Session.TitleFormats {
+				merged[rot] = f
+			}
+			cfg.Session.TitleFormats = merged
+		}
 	}
 	if y.Defaults != nil {
 		if y.Defaults.Agent != nil {
@@ -258,12 +508,41 @@ func applyYAML(cfg *Config, y yamlConfig) {
 	}
 }
 
+func applyYAMLKeys(cfg *Config, keys map[string][]string) {
+	if len(keys) == 0 {
+		return
+	}
+	merged := make(map[string][]string, len(cfg.Keys)+len(keys))
+	for action, k := range cfg.Keys {
+		merged[action] = k
+	}
+	for action, k := range keys {
+		merged[action] = k
+	}
+	cfg.Keys = merged
+}
+
+// setsAddress reports whether o names a server: host, port or base_url.
+func (o Options) setsAddress() bool {
+	return o.Host != nil || o.Port != nil || o.BaseURL != nil
+}
+
 func applyOptions(cfg Config, opts Options) Config {
+	// An explicit host or port means "connect there", so it also overrides a
+	// base_url or socket from the file; likewise a base_url overrides a socket.
 	if opts.Host != nil {
 		cfg.Server.Host = *opts.Host
+		cfg.Server.BaseURL = ""
+		cfg.Server.Socket = ""
 	}
 	if opts.Port != nil {
 		cfg.Server.Port = *opts.Port
+		cfg.Server.BaseURL = ""
+		cfg.Server.Socket = ""
+	}
+	if opts.BaseURL != nil {
+		cfg.Server.BaseURL = *opts.BaseURL
+		cfg.Server.Socket = ""
 	}
 	if opts.DefaultSession != nil {
 		cfg.Session.DefaultSession = *opts.DefaultSession
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -87,3 +88,118 @@ func TestLoadMissingFileUsesDefaults(t *testing.T) {
 		t.Fatalf("defaults not applied: %+v", cfg.Server)
 	}
 }
+
+func TestLoadAppliesProfile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "miniopencode.yaml")
+	yamlContent := `server:
+  host: 127.0.0.1
+  port: 4096
+defaults:
+  agent: build
+  model_id: base-model
+profiles:
+  buildbox:
+    server:
+      host: buildbox.internal
+      port: 8080
+    defaults:
+      model_id: big-model
+    theme:
+      status_color: "#ff0000"
+  sandbox:
+    server:
+      socket: /run/sandbox.sock
+`
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+
+	cfg, err := Load(yamlPath, Options{Profile: strPtr("buildbox")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Profile != "buildbox" || cfg.Server.Host != "buildbox.internal" || cfg.Server.Port != 8080 {
+		t.Fatalf("profile server not applied: %+v", cfg.Server)
+	}
+	if cfg.Defaults.Agent != "build" || cfg.Defaults.ModelID != "big-model" {
+		t.Fatalf("profile should override only the keys it sets: %+v", cfg.Defaults)
+	}
+	if cfg.Theme.StatusColor != "#ff0000" {
+		t.Fatalf("profile theme not applied: %+v", cfg.Theme)
+	}
+
+	cfg, err = Load(yamlPath, Options{Profile: strPtr("buildbox"), Port: intPtr(9999)})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Host != Default().Server.Host || cfg.Server.Port != 9999 {
+		t.Fatalf("cli flags should replace the profile server: %+v", cfg.Server)
+	}
+
+	t.Setenv(ProfileEnv, "sandbox")
+	cfg, err = Load(yamlPath, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Profile != "sandbox" || cfg.Server.Socket != "/run/sandbox.sock" {
+		t.Fatalf("env profile not applied: %+v", cfg)
+	}
+	cfg, err = Load(yamlPath, Options{Profile: strPtr("buildbox")})
+	if err != nil || cfg.Profile != "buildbox" {
+		t.Fatalf("--profile should win over the env var: %v %q", err, cfg.Profile)
+	}
+
+	if _, err := Load(yamlPath, Options{Profile: strPtr("nope")}); err == nil || !strings.Contains(err.Error(), "buildbox, sandbox") {
+		t.Fatalf("expected unknown profile error listing profiles, got %v", err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml"), Options{Profile: strPtr("buildbox")}); err == nil {
+		t.Fatalf("expected error selecting a profile without a config file")
+	}
+}
+
+func TestProfileServerReplacesBaseServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miniopencode.yaml")
+	yamlContent := `server:
+  socket: /run/opencode.sock
+  base_url: https://local.example
+  auth:
+    token: local-secret
+  headers:
+    X-Tenant: local
+profiles:
+  remote:
+    server:
+      host: buildbox.internal
+      port: 8080
+  theme-only:
+    theme:
+      status_color: "#ff0000"
+`
+	if err := os.WriteFile(path, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+
+	cfg, report, err := LoadDetailed(path, Options{Profile: strPtr("remote")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := cfg.Server
+	if s.Host != "buildbox.internal" || s.Port != 8080 || s.Socket != "" || s.BaseURL != "" {
+		t.Fatalf("profile host/port should replace the base socket and base_url: %+v", s)
+	}
+	if s.Auth.Token != "" || len(s.Headers) != 0 {
+		t.Fatalf("base credentials must not carry over to the profile's server: %+v", s)
+	}
+	if src := report.Sources.Of("server.auth.token"); src != SourceDefault {
+		t.Fatalf("reset token should be reported as default, got %s", src)
+	}
+
+	cfg, err = Load(path, Options{Profile: strPtr("theme-only")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Socket != "/run/opencode.sock" || cfg.Server.Auth.Token != "local-secret" {
+		t.Fatalf("a profile without a server address should keep the base server: %+v", cfg.Server)
+	}
+}
@@ -0,0 +1,192 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source says which layer supplied an effective config value.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceProfile Source = "profile"
+	SourceProject Source = "project"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Sources maps dotted config keys ("server.host", "ui.mode") to the layer
+// that last set them. Keys not present come from the defaults.
+type Sources map[string]Source
+
+// Of returns the source of key.
+func (s Sources) Of(key string) Source {
+	if src, ok := s[key]; ok {
+		return src
+	}
+	return SourceDefault
+}
+
+// EnvPrefix starts every environment variable read by Load.
+const EnvPrefix = "MINIOPENCODE_"
+
+// optionField ties an Options field to its config key. The environment
+// variable is EnvPrefix plus the upper-cased key with dots as underscores;
+// legacy names are consulted when it is unset.
+type optionField struct {
+	key    string
+	legacy string
+	field  func(*Options) any
+}
+
+var optionFields = []optionField{
+	{key: "server.host", legacy: "OPENCODE_HOST", field: func(o *Options) any { return &o.Host }},
+	{key: "server.port", legacy: "OPENCODE_PORT", field: func(o *Options) any { return &o.Port }},
+	{key: "server.base_url", field: func(o *Options) any { return &o.BaseURL }},
+	{key: "session.default_session", field: func(o *Options) any { return &o.DefaultSession }},
+	{key: "session.daily_max_tokens", field: func(o *Options) any { return &o.DailyMaxTokens }},
+	{key: "session.daily_max_messages", field: func(o *Options) any { return &o.DailyMaxMessages }},
+	{key: "ui.mode", field: func(o *Options) any { return &o.Mode }},
+	{key: "ui.show_thinking", field: func(o *Options) any { return &o.ShowThinking }},
+	{key: "ui.show_tools", field: func(o *Options) any { return &o.ShowTools }},
+	{key: "ui.wrap", field: func(o *Options) any { return &o.Wrap }},
+	{key: "ui.input_height", field: func(o *Options) any { return &o.InputHeight }},
+	{key: "ui.max_output_lines", field: func(o *Options) any { return &o.MaxOutputLines }},
+	{key: "ui.theme", field: func(o *Options) any { return &o.Theme }},
+	{key: "defaults.agent", field: func(o *Options) any { return &o.Agent }},
+	{key: "defaults.provider_id", field: func(o *Options) any { return &o.ProviderID }},
+	{key: "defaults.model_id", field: func(o *Options) any { return &o.ModelID }},
+}
+
+// EnvName returns the environment variable overriding the config key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// envOptions reads the environment layer through getenv.
+func envOptions(getenv func(string) string) (Options, error) {
+	var opts Options
+	for _, f := range optionFields {
+		name := EnvName(f.key)
+		value := getenv(name)
+		if value == "" && f.legacy != "" {
+			name, value = f.legacy, getenv(f.legacy)
+		}
+		if value == "" {
+			continue
+		}
+		switch p := f.field(&opts).(type) {
+		case **string:
+			*p = &value
+		case **int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return opts, fmt.Errorf("%s: invalid integer %q", name, value)
+			}
+			*p = &n
+		case **bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return opts, fmt.Errorf("%s: invalid boolean %q", name, value)
+			}
+			*p = &b
+		}
+	}
+	return opts, nil
+}
+
+// markOptions records src for every field set in opts.
+func (s Sources) markOptions(opts Options, src Source) {
+	for _, f := range optionFields {
+		set := false
+		switch p := f.field(&opts).(type) {
+		case **string:
+			set = *p != nil
+		case **int:
+			set = *p != nil
+		case **bool:
+			set = *p != nil
+		}
+		if !set {
+			continue
+		}
+		s[f.key] = src
+		// An explicit host or port replaces base_url and socket, and a
+		// base_url replaces socket (see applyOptions).
+		var replaced []string
+		switch f.key {
+		case "server.host", "server.port":
+			replaced = []string{"server.base_url", "server.socket"}
+		case "server.base_url":
+			replaced = []string{"server.socket"}
+		}
+		for _, k := range replaced {
+			if _, ok := s[k]; ok {
+				s[k] = src
+			}
+		}
+	}
+}
+
+// markYAML records the keys set by the file and by the selected profile.
+func (s Sources) markYAML(data []byte, profile string) {
+	var doc map[string]any
+	if yaml.Unmarshal(data, &doc) != nil {
+		return
+	}
+	profiles, _ := doc["profiles"].(map[string]any)
+	delete(doc, "profiles")
+	s.markKeys("", doc, SourceFile)
+	if p, ok := profiles[profile].(map[string]any); ok && profile != "" {
+		// A profile naming another server replaces the whole server section
+		// (see parseYAML).
+		server, _ := p["server"].(map[string]any)
+		for _, k := range []string{"host", "port", "base_url", "socket"} {
+			if _, ok := server[k]; ok {
+				s.resetServer()
+				break
+			}
+		}
+		s.markKeys("", p, SourceProfile)
+	}
+}
+
+// resetServer forgets the sources of every server key, for a layer that
+// replaces the whole server section.
+func (s Sources) resetServer() {
+	for key := range s {
+		if strings.HasPrefix(key, "server.") {
+			delete(s, key)
+		}
+	}
+}
+
+// markProject records the keys set by a project overlay.
+func (s Sources) markProject(data []byte) {
+	var doc map[string]any
+	if yaml.Unmarshal(data, &doc) != nil {
+		return
+	}
+	for section := range doc {
+		if !projectSections[section] {
+			delete(doc, section)
+		}
+	}
+	s.markKeys("", doc, SourceProject)
+}
+
+func (s Sources) markKeys(prefix string, m map[string]any, src Source) {
+	for k, v := range m {
+		key := prefix + k
+		if sub, ok := v.(map[string]any); ok && key != "server.headers" {
+			s.markKeys(key+".", sub, src)
+			continue
+		}
+		s[key] = src
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("server.base_url"); got != "MINIOPENCODE_SERVER_BASE_URL" {
+		t.Fatalf("unexpected env name: %s", got)
+	}
+}
+
+func TestLoadEnvLayerBetweenYAMLAndFlags(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "miniopencode.yaml")
+	yamlContent := `server:
+  host: yaml-host
+  port: 1111
+ui:
+  mode: input
+  show_tools: false
+`
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	t.Setenv("MINIOPENCODE_SERVER_HOST", "env-host")
+	t.Setenv("MINIOPENCODE_UI_MODE", "output")
+	t.Setenv("MINIOPENCODE_UI_SHOW_TOOLS", "true")
+	t.Setenv("MINIOPENCODE_SESSION_DAILY_MAX_TOKENS", "123")
+	t.Setenv("OPENCODE_PORT", "2222")
+
+	cfg, report, err := LoadDetailed(yamlPath, Options{Mode: strPtr("full")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Host != "env-host" || cfg.Server.Port != 2222 {
+		t.Fatalf("env should override yaml server: %+v", cfg.Server)
+	}
+	if cfg.UI.Mode != "full" || !cfg.UI.ShowTools || cfg.Session.DailyMaxTokens != 123 {
+		t.Fatalf("unexpected merge: ui=%+v session=%+v", cfg.UI, cfg.Session)
+	}
+
+	want := map[string]Source{
+		"server.host":              SourceEnv,
+		"server.port":              SourceEnv,
+		"ui.mode":                  SourceFlag,
+		"ui.show_tools":            SourceEnv,
+		"session.daily_max_tokens": SourceEnv,
+		"ui.wrap":                  SourceDefault,
+	}
+	for key, src := range want {
+		if got := report.Sources.Of(key); got != src {
+			t.Errorf("%s: expected source %s, got %s", key, src, got)
+		}
+	}
+
+	t.Setenv("MINIOPENCODE_SERVER_PORT", "4444")
+	if cfg, _ := Load(yamlPath, Options{}); cfg.Server.Port != 4444 {
+		t.Fatalf("MINIOPENCODE_SERVER_PORT should win over OPENCODE_PORT: %d", cfg.Server.Port)
+	}
+}
+
+func TestLoadRejectsInvalidEnvValues(t *testing.T) {
+	for name, value := range map[string]string{
+		"MINIOPENCODE_SERVER_PORT":   "http",
+		"MINIOPENCODE_UI_WRAP":       "sometimes",
+		"MINIOPENCODE_UI_SHOW_TOOLS": "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), Options{})
+			if value == "" {
+				if err != nil {
+					t.Fatalf("empty value should be ignored: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error for %s=%q", name, value)
+			}
+		})
+	}
+}
+
+func TestSourcesTrackFileAndProfile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "miniopencode.yaml")
+	yamlContent := `server:
+  port: 1111
+  headers:
+    X-Tenant: acme
+profiles:
+  remote:
+    defaults:
+      model_id: big-model
+`
+	if err := os.WriteFile(yamlPath, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	_, report, err := LoadDetailed(yamlPath, Options{Profile: strPtr("remote")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	for key, src := range map[string]Source{
+		"server.port":       SourceFile,
+		"server.headers":    SourceFile,
+		"defaults.model_id": SourceProfile,
+		"ui.mode":           SourceDefault,
+	} {
+		if got := report.Sources.Of(key); got != src {
+			t.Errorf("%s: expected source %s, got %s", key, src, got)
+		}
+	}
+}
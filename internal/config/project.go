@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFileName is the per-project overlay looked up from the working
+// directory upwards.
+const ProjectFileName = ".miniopencode.yaml"
+
+// projectSections are the top-level sections a project overlay may set.
+// Server settings and profiles stay in the user config so that a checked-out
+// repository cannot redirect prompts or credentials.
+var projectSections = map[string]bool{
+	"session":  true,
+	"defaults": true,
+	"ui":       true,
+	"theme":    true,
+	"keys":     true,
+}
+
+// FindProjectConfig walks up from dir and returns the first
+// ProjectFileName found.
+func FindProjectConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// applyProjectYAML merges a project overlay into cfg, returning a warning
+// for each section it is not allowed to set.
+func applyProjectYAML(cfg *Config, path string, data []byte) ([]Issue, error) {
+	var y yamlConfig
+	if err := yaml.Unmarshal(data, &y); err != nil {
+		return nil, err
+	}
+	applyYAML(cfg, yamlConfig{Session: y.Session, Defaults: y.Defaults, UI: y.UI, Theme: y.Theme})
+	applyYAMLKeys(cfg, y.Keys)
+
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+		return nil, nil
+	}
+	var warnings []Issue
+	doc := root.Content[0]
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		k := doc.Content[i]
+		if _, known := fieldByTag(reflect.TypeOf(yamlConfig{}), k.Value); known && !projectSections[k.Value] {
+			warnings = append(warnings, Issue{File: path, Line: k.Line, Key: k.Value, Source: SourceProject,
+				Message: "not allowed in a project config; set it in the user config"})
+		}
+	}
+	return warnings, nil
+}
+
+// sameFile reports whether a and b name the same existing file.
+func sameFile(a, b string) bool {
+	ia, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	ib, err := os.Stat(b)
+	return err == nil && os.SameFile(ia, ib)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMergesProjectOverlay(t *testing.T) {
+	userPath := writeConfig(t, `server:
+  host: user-host
+defaults:
+  agent: build
+  provider_id: anthropic
+`)
+	repo := t.TempDir()
+	project := filepath.Join(repo, ProjectFileName)
+	if err := os.WriteFile(project, []byte(`defaults:
+  agent: plan
+  model_id: project-model
+session:
+  default_session: repo-session
+server:
+  host: evil.example
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(repo, "pkg", "sub")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(nested)
+
+	cfg, report, err := LoadDetailed(userPath, Options{ModelID: strPtr("cli-model")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if report.ProjectPath != project {
+		t.Fatalf("expected project %s, got %q", project, report.ProjectPath)
+	}
+	if cfg.Defaults.Agent != "plan" || cfg.Defaults.ProviderID != "anthropic" || cfg.Defaults.ModelID != "cli-model" {
+		t.Fatalf("unexpected defaults: %+v", cfg.Defaults)
+	}
+	if cfg.Session.DefaultSession != "repo-session" {
+		t.Fatalf("project default_session not applied: %+v", cfg.Session)
+	}
+	if cfg.Server.Host != "user-host" {
+		t.Fatalf("project config must not change the server: %+v", cfg.Server)
+	}
+	if report.Sources.Of("defaults.agent") != SourceProject || report.Sources.Of("defaults.model_id") != SourceFlag ||
+		report.Sources.Of("server.host") != SourceFile {
+		t.Fatalf("unexpected sources: %v", report.Sources)
+	}
+	if len(report.Warnings) != 1 || report.Warnings[0].Key != "server" || report.Warnings[0].Line != 6 {
+		t.Fatalf("expected a warning for the server section, got %v", report.Warnings)
+	}
+}
+
+func TestProjectOverlayErrorsNameTheProjectFile(t *testing.T) {
+	repo := t.TempDir()
+	project := filepath.Join(repo, ProjectFileName)
+	if err := os.WriteFile(project, []byte("ui:\n  mode: sideways\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(repo)
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"), Options{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || !strings.HasPrefix(verr.Issues[0].Error(), project+":2: ui.mode") {
+		t.Fatalf("expected error pointing at the project file, got %v", err)
+	}
+}
+
+func TestFindProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok := FindProjectConfig(dir); ok {
+		t.Skip("a project config exists above the temp dir")
+	}
+	if err := os.Mkdir(filepath.Join(dir, ProjectFileName), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindProjectConfig(dir); ok {
+		t.Fatal("a directory must not count as a project config")
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"miniopencode/internal/client"
+)
+
+// ClientConfig turns the server section into a client.Config, loading any
+// TLS material from disk.
+func (s ServerConfig) ClientConfig() (client.Config, error) {
+	cc := client.Config{
+		Host:     s.Host,
+		Port:     s.Port,
+		Socket:   s.Socket,
+		Username: s.Auth.Username,
+		Password: s.Auth.Password,
+		Token:    s.Auth.Token,
+		Headers:  s.Headers,
+	}
+	if s.BaseURL != "" {
+		u, err := url.Parse(s.BaseURL)
+		if err != nil {
+			return cc, fmt.Errorf("server.base_url: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return cc, fmt.Errorf("server.base_url: scheme must be http or https, got %q", u.Scheme)
+		}
+		if u.Host == "" {
+			return cc, fmt.Errorf("server.base_url: missing host in %q", s.BaseURL)
+		}
+		cc.BaseURL = strings.TrimRight(s.BaseURL, "/")
+	}
+	if s.Auth.Password != "" && s.Auth.Username == "" {
+		return cc, fmt.Errorf("server.auth: password set without username")
+	}
+
+	t := s.TLS
+	if t.CAFile == "" && t.CertFile == "" && t.KeyFile == "" {
+		return cc, nil
+	}
+	cc.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return cc, fmt.Errorf("server.tls.ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return cc, fmt.Errorf("server.tls.ca_file: no certificates found in %s", t.CAFile)
+		}
+		cc.TLS.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return cc, fmt.Errorf("server.tls: cert_file and key_file must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return cc, fmt.Errorf("server.tls: %w", err)
+		}
+		cc.TLS.Certificates = []tls.Certificate{cert}
+	}
+	return cc, nil
+}
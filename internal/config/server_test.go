@@ -0,0 +1,192 @@
+package config
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"miniopencode/internal/client"
+)
+
+func TestLoadServerConnectionSettings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "miniopencode.yaml")
+	yamlContent := `server:
+  base_url: https://example.com/opencode
+  auth:
+    username: alice
+    password: secret
+  headers:
+    X-Tenant: acme
+  tls:
+    ca_file: /etc/ca.pem
+`
+	if err := os.WriteFile(path, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	cfg, err := Load(path, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	s := cfg.Server
+	if s.BaseURL != "https://example.com/opencode" || s.Auth.Username != "alice" || s.Auth.Password != "secret" ||
+		s.Headers["X-Tenant"] != "acme" || s.TLS.CAFile != "/etc/ca.pem" {
+		t.Fatalf("unexpected server config: %+v", s)
+	}
+
+	if err := os.WriteFile(path, []byte(yamlContent+"  socket: /run/oc.sock\n"), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	cfg, err = Load(path, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Socket != "/run/oc.sock" {
+		t.Fatalf("unexpected socket: %+v", cfg.Server)
+	}
+
+	cfg, err = Load(path, Options{Port: intPtr(5000)})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.BaseURL != "" || cfg.Server.Socket != "" || cfg.Server.Port != 5000 {
+		t.Fatalf("--port should override base_url and socket: %+v", cfg.Server)
+	}
+}
+
+func TestBaseURLOverridesSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miniopencode.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  socket: /run/oc.sock\n"), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+
+	cfg, report, err := LoadDetailed(path, Options{BaseURL: strPtr("https://remote.example")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Socket != "" || cfg.Server.BaseURL != "https://remote.example" {
+		t.Fatalf("--url should override socket: %+v", cfg.Server)
+	}
+	if src := report.Sources.Of("server.socket"); src != SourceDefault {
+		t.Fatalf("socket should be reset to its default by the flag, got %s", src)
+	}
+
+	t.Setenv(EnvName("server.base_url"), "https://env.example")
+	cfg, err = Load(path, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Socket != "" || cfg.Server.BaseURL != "https://env.example" {
+		t.Fatalf("$%s should override socket: %+v", EnvName("server.base_url"), cfg.Server)
+	}
+}
+
+func TestServerOverridesDropCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miniopencode.yaml")
+	yamlContent := `server:
+  host: home.example
+  auth:
+    username: alice
+    password: secret
+    token: abc
+  headers:
+    X-Tenant: acme
+  tls:
+    cert_file: /etc/client.pem
+    key_file: /etc/client-key.pem
+`
+	if err := os.WriteFile(path, []byte(yamlContent), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	noCredentials := func(t *testing.T, s ServerConfig) {
+		t.Helper()
+		if s.Auth != (AuthConfig{}) || len(s.Headers) != 0 || s.TLS != (TLSConfig{}) {
+			t.Fatalf("credentials must not follow an overridden server: %+v", s)
+		}
+	}
+
+	cfg, report, err := LoadDetailed(path, Options{Host: strPtr("other")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Host != "other" || cfg.Server.Port != Default().Server.Port {
+		t.Fatalf("unexpected server: %+v", cfg.Server)
+	}
+	noCredentials(t, cfg.Server)
+	if src := report.Sources.Of("server.auth.username"); src != SourceDefault {
+		t.Fatalf("auth should be reported as default, got %s", src)
+	}
+
+	cfg, err = Load(path, Options{BaseURL: strPtr("https://other.example")})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	noCredentials(t, cfg.Server)
+
+	t.Setenv("OPENCODE_PORT", "5000")
+	cfg, err = Load(path, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Host != Default().Server.Host || cfg.Server.Port != 5000 {
+		t.Fatalf("unexpected server: %+v", cfg.Server)
+	}
+	noCredentials(t, cfg.Server)
+
+	t.Setenv(EnvName("server.host"), "env-host")
+	cfg, err = Load(path, Options{Port: intPtr(6000)})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Host != "env-host" || cfg.Server.Port != 6000 {
+		t.Fatalf("env and flag overrides should combine: %+v", cfg.Server)
+	}
+}
+
+func TestClientConfigRejectsBadSettings(t *testing.T) {
+	tests := []ServerConfig{
+		{BaseURL: "ftp://example.com"},
+		{BaseURL: "https://"},
+		{Auth: AuthConfig{Password: "p"}},
+		{TLS: TLSConfig{CertFile: "cert.pem"}},
+		{TLS: TLSConfig{CAFile: "/does/not/exist.pem"}},
+	}
+	for _, s := range tests {
+		if _, err := s.ClientConfig(); err == nil {
+			t.Errorf("expected error for %+v", s)
+		}
+	}
+}
+
+func TestClientConfigTrustsCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, block, 0o600); err != nil {
+		t.Fatalf("write ca: %v", err)
+	}
+
+	untrusted, err := ServerConfig{BaseURL: srv.URL}.ClientConfig()
+	if err != nil {
+		t.Fatalf("client config: %v", err)
+	}
+	if err := client.New(untrusted).Health(context.Background()); err == nil {
+		t.Fatalf("expected certificate error without the CA bundle")
+	}
+
+	trusted, err := ServerConfig{BaseURL: srv.URL, TLS: TLSConfig{CAFile: caFile}}.ClientConfig()
+	if err != nil {
+		t.Fatalf("client config: %v", err)
+	}
+	if err := client.New(trusted).Health(context.Background()); err != nil {
+		t.Fatalf("health over TLS: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redactedValue = "********"
+
+// redact hides credentials before a config is printed. Every header value is
+// hidden, since headers often carry tokens under arbitrary names; the names
+// stay visible.
+func redact(cfg Config) Config {
+	if cfg.Server.Auth.Password != "" {
+		cfg.Server.Auth.Password = redactedValue
+	}
+	if cfg.Server.Auth.Token != "" {
+		cfg.Server.Auth.Token = redactedValue
+	}
+	if len(cfg.Server.Headers) > 0 {
+		headers := make(map[string]string, len(cfg.Server.Headers))
+		for name := range cfg.Server.Headers {
+			headers[name] = redactedValue
+		}
+		cfg.Server.Headers = headers
+	}
+	return cfg
+}
+
+// effectiveNode encodes the redacted cfg and calls leaf for every value with
+// its dotted key and nodes. server.headers counts as one value.
+func effectiveNode(cfg Config, leaf func(key string, k, v *yaml.Node)) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := doc.Encode(redact(cfg)); err != nil {
+		return nil, err
+	}
+	var walk func(n *yaml.Node, prefix string)
+	walk = func(n *yaml.Node, prefix string) {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			key := prefix + k.Value
+			if v.Kind == yaml.MappingNode && key != "server.headers" {
+				walk(v, key+".")
+				continue
+			}
+			leaf(key, k, v)
+		}
+	}
+	walk(&doc, "")
+	return &doc, nil
+}
+
+func configDescription(report Report, profile string) string {
+	desc := "config file: " + report.Path
+	if _, err := os.Stat(report.Path); err != nil {
+		desc += " (not found)"
+	}
+	if report.ProjectPath != "" {
+		desc += "\nproject file: " + report.ProjectPath
+	}
+	if profile != "" {
+		desc += "\nprofile: " + profile
+	}
+	return desc
+}
+
+// EffectiveYAML renders cfg as YAML, annotating every value with the layer
+// it came from. Credentials are redacted.
+func EffectiveYAML(cfg Config, report Report) ([]byte, error) {
+	doc, err := effectiveNode(cfg, func(key string, k, v *yaml.Node) {
+		src := string(report.Sources.Of(key))
+		if v.Kind == yaml.ScalarNode || len(v.Content) == 0 {
+			v.LineComment = src
+		} else {
+			k.LineComment = src
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	doc.HeadComment = configDescription(report, cfg.Profile)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EffectiveJSON renders cfg as JSON together with the source of every key.
+// Credentials are redacted.
+func EffectiveJSON(cfg Config, report Report) ([]byte, error) {
+	sources := map[string]Source{}
+	doc, err := effectiveNode(cfg, func(key string, _, _ *yaml.Node) {
+		sources[key] = report.Sources.Of(key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := doc.Decode(&values); err != nil {
+		return nil, fmt.Errorf("decode config: %w", err)
+	}
+	return json.MarshalIndent(map[string]any{
+		"path":    report.Path,
+		"project": report.ProjectPath,
+		"profile": cfg.Profile,
+		"config":  values,
+		"sources": sources,
+	}, "", "  ")
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFileMatchesDefaults(t *testing.T) {
+	path := writeConfig(t, DefaultFile)
+	cfg, report, err := LoadDetailed(path, Options{})
+	if err != nil {
+		t.Fatalf("default file should be valid: %v", err)
+	}
+	if len(report.Warnings) != 0 {
+		t.Fatalf("default file should have no unknown keys: %v", report.Warnings)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Fatalf("default file drifted from Default():\n%+v\n%+v", cfg, Default())
+	}
+}
+
+func TestWriteDefaultRefusesToOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "miniopencode.yaml")
+	if err := WriteDefault(path, false); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected private file: %v %v", info, err)
+	}
+	if err := WriteDefault(path, false); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected ErrExist, got %v", err)
+	}
+	if err := WriteDefault(path, true); err != nil {
+		t.Fatalf("force overwrite: %v", err)
+	}
+}
+
+func TestEffectiveOutputAnnotatesSourcesAndRedacts(t *testing.T) {
+	path := writeConfig(t, `server:
+  auth:
+    username: alice
+    token: hunter2
+  headers:
+    X-Api-Key: sekrit
+ui:
+  mode: input
+`)
+	cfg, report, err := LoadDetailed(path, Options{InputHeight: intPtr(7)})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	out, err := EffectiveYAML(cfg, report)
+	if err != nil {
+		t.Fatalf("yaml: %v", err)
+	}
+	text := string(out)
+	for _, want := range []string{
+		"# config file: " + path,
+		"input_height: 7 # flag",
+		"mode: input # file",
+		"wrap: true # default",
+		"token: '********' # file",
+		"X-Api-Key: '********'",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "hunter2") || strings.Contains(text, "sekrit") {
+		t.Fatalf("credentials must be redacted:\n%s", text)
+	}
+	if cfg.Server.Headers["X-Api-Key"] != "sekrit" {
+		t.Fatalf("redaction must not modify the loaded config: %v", cfg.Server.Headers)
+	}
+
+	out, err = EffectiveJSON(cfg, report)
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var doc struct {
+		Path    string
+		Config  map[string]map[string]any
+		Sources map[string]Source
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid json %s: %v", out, err)
+	}
+	if strings.Contains(string(out), "sekrit") {
+		t.Fatalf("header values must be redacted: %s", out)
+	}
+	if doc.Path != path || doc.Config["ui"]["mode"] != "input" {
+		t.Fatalf("unexpected json: %s", out)
+	}
+	if doc.Sources["ui.input_height"] != SourceFlag || doc.Sources["server.auth.username"] != SourceFile || doc.Sources["ui.wrap"] != SourceDefault {
+		t.Fatalf("unexpected sources: %v", doc.Sources)
+	}
+}
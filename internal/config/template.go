@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultFile is a commented config file holding the built-in defaults, as
+// written by `miniopencode config init`.
+const DefaultFile = `# miniopencode configuration.
+# Values shown are the defaults; delete any line to keep the default.
+# Environment variables (MINIOPENCODE_<SECTION>_<KEY>) and CLI flags
+# override this file.
+
+server:
+  host: 127.0.0.1
+  port: 4096
+  # base_url: https://dev.example.com/opencode  # overrides host/port
+  # socket: /run/user/1000/opencode.sock        # Unix socket instead of TCP
+  # auth:
+  #   username: alice
+  #   password: s3cret
+  #   token: abc123  # bearer; takes precedence over username/password
+  # headers:
+  #   X-Tenant: acme
+  # tls:
+  #   ca_file: /etc/ssl/internal-ca.pem
+  #   cert_file: /etc/ssl/client.pem
+  #   key_file: /etc/ssl/client-key.pem
+
+session:
+  # "" picks a session named "miniopencode"; daily, weekly, monthly, branch
+  # and cwd rotate numbered sessions; anything else is a session ID or title.
+  default_session: ""
+  daily_title_format: "2006-01-02-daily-%d"
+  # Title formats of the other rotations, each with one %d:
+  # title_formats:
+  #   weekly: "2006-01-02-weekly-%d"
+  #   monthly: "2006-01-monthly-%d"
+  #   branch: "{repo}@{branch}-%d"
+  #   cwd: "{path} #%d"
+  # Rotations that ask the model to summarize the previous part when rolling
+  # over and seed the new part with that summary (none by default):
+  # carry_summary: [daily, branch]
+  daily_max_tokens: 250000
+  daily_max_messages: 4000
+  # How a session name finds an existing session by title when it is not an
+  # ID: exact | prefix | fuzzy (case-insensitive substring). The most
+  # recently updated match wins; a session is created only if none match.
+  match: exact
+
+defaults:
+  # Empty values use the server's defaults.
+  agent: ""
+  provider_id: ""
+  model_id: ""
+
+ui:
+  mode: full  # input | output | full
+  show_thinking: true
+  show_tools: true
+  wrap: true
+  input_height: 6
+  max_output_lines: 4000
+  theme: default
+
+theme:
+  border_style: rounded  # rounded | normal | thick | double | hidden
+  output_border_color: "#89b4fa"
+  input_border_color: "#a6e3a1"
+  status_color: "#6c7086"
+  thinking_color: "#f9e2af"
+  tool_color: "#94e2d5"
+  answer_color: "#cdd6f4"
+
+# Rebind TUI actions: quit, help, send, resize_up, resize_down, up, down,
+# page_up, page_down, half_up, half_down, top, bottom.
+# keys:
+#   quit: [ctrl+c, ctrl+q]
+#   send: [enter]
+
+# Named profiles, selected with --profile NAME or MINIOPENCODE_PROFILE.
+# Each may override server, session, defaults and theme.
+# profiles:
+#   remote:
+#     server:
+#       base_url: https://buildbox.internal/opencode
+#     defaults:
+#       model_id: claude-opus-4
+`
+
+// WriteDefault writes DefaultFile to path, creating parent directories. It
+// refuses to replace an existing file unless force is set; the error then
+// wraps os.ErrExist.
+func WriteDefault(path string, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	// The file may later hold credentials, so keep it private.
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%w: %s (use --force to overwrite)", os.ErrExist, path)
+		}
+		return err
+	}
+	if _, err := f.WriteString(DefaultFile); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a problem with one config key. Line is set when the value came
+// from a file; otherwise Source says which layer supplied it.
+type Issue struct {
+	File    string
+	Line    int
+	Key     string
+	Source  Source
+	Message string
+}
+
+func (i Issue) Error() string {
+	switch {
+	case i.Line > 0:
+		return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Key, i.Message)
+	case i.Source == SourceEnv:
+		return fmt.Sprintf("$%s: %s: %s", EnvName(i.Key), i.Key, i.Message)
+	case i.Source == SourceFlag:
+		return fmt.Sprintf("command line: %s: %s", i.Key, i.Message)
+	default:
+		return fmt.Sprintf("%s: %s", i.Key, i.Message)
+	}
+}
+
+// ValidationError lists every invalid value found by Load.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		msgs[i] = issue.Error()
+	}
+	return "invalid config:\n  " + strings.Join(msgs, "\n  ")
+}
+
+// Modes accepted by ui.mode.
+var Modes = []string{"input", "output", "full"}
+
+// KeyActions are the TUI actions that can be rebound under keys:.
+var KeyActions = []string{
+	"quit", "help", "send", "resize_up", "resize_down", "up", "down",
+	"page_up", "page_down", "half_up", "half_down", "top", "bottom",
+}
+
+// SessionMatches accepted by session.match.
+var SessionMatches = []string{"exact", "prefix", "fuzzy"}
+
+// Rotations are the default_session values that rotate through numbered
+// sessions, and the keys accepted by session.title_formats.
+var Rotations = []string{"daily", "weekly", "monthly", "branch", "cwd"}
+
+// timeRotations are the rotations whose title formats are Go time layouts.
+var timeRotations = []string{"daily", "weekly", "monthly"}
+
+// unterminatedQuote reports whether a time layout title format leaves a
+// quoted literal open on either side of its %d; '' stands for a quote.
+func unterminatedQuote(format string) bool {
+	before, after, _ := strings.Cut(format, "%d")
+	for _, half := range []string{before, after} {
+		if strings.Count(strings.ReplaceAll(half, "''", ""), "'")%2 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// BorderStyles accepted by theme.border_style.
+var BorderStyles = []string{"rounded", "normal", "thick", "double", "hidden"}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor accepts "#rgb", "#rrggbb" or an ANSI color number 0-255.
+func validColor(s string) bool {
+	if hexColor.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// validate checks the effective cfg. locate returns the file and line that
+// set a key from a file-backed source.
+func validate(cfg Config, sources Sources, locate func(key string, src Source) (string, int)) error {
+	var issues []Issue
+	fail := func(key, format string, args ...any) {
+		issue := Issue{Key: key, Source: sources.Of(key), Message: fmt.Sprintf(format, args...)}
+		issue.File, issue.Line = locate(key, issue.Source)
+		issues = append(issues, issue)
+	}
+
+	s := cfg.Server
+	if s.Port < 1 || s.Port > 65535 {
+		fail("server.port", "must be between 1 and 65535, got %d", s.Port)
+	}
+	if s.Host == "" && s.BaseURL == "" && s.Socket == "" {
+		fail("server.host", "must not be empty")
+	}
+	if s.BaseURL != "" {
+		if u, err := url.Parse(s.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("server.base_url", "must be an http:// or https:// URL, got %q", s.BaseURL)
+		}
+	}
+	if s.Auth.Password != "" && s.Auth.Username == "" {
+		fail("server.auth.password", "set without server.auth.username")
+	}
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		fail("server.tls.cert_file", "cert_file and key_file must be set together")
+	}
+
+	if cfg.Session.DailyMaxTokens < 0 {
+		fail("session.daily_max_tokens", "must not be negative, got %d", cfg.Session.DailyMaxTokens)
+	}
+	if cfg.Session.DailyMaxMessages < 0 {
+		fail("session.daily_max_messages", "must not be negative, got %d", cfg.Session.DailyMaxMessages)
+	}
+	if f := cfg.Session.DailyTitleFormat; f != "" {
+		switch {
+		case strings.Count(f, "%d") != 1:
+			fail("session.daily_title_format", "must contain %%d exactly once for the part number, got %q", f)
+		case unterminatedQuote(f):
+			fail("session.daily_title_format", "has an unterminated ' quote before or after %%d, got %q", f)
+		}
+	}
+
+	for rot, f := range cfg.Session.TitleFormats {
+		key := "session.title_formats." + rot
+		switch {
+		case !slices.Contains(Rotations, rot):
+			fail(key, "unknown rotation (want one of %s)", strings.Join(Rotations, ", "))
+		case strings.Count(f, "%d") != 1:
+			fail(key, "must contain %%d exactly once for the part number, got %q", f)
+		case slices.Contains(timeRotations, rot) && unterminatedQuote(f):
+			fail(key, "has an unterminated ' quote before or after %%d, got %q", f)
+		}
+	}
+
+	for _, rot := range cfg.Session.CarrySummary {
+		if !slices.Contains(Rotations, rot) {
+			fail("session.carry_summary", "unknown rotation %q (want one of %s)", rot, strings.Join(Rotations, ", "))
+		}
+	}
+
+	if !slices.Contains(SessionMatches, cfg.Session.Match) {
+		fail("session.match", "must be one of %s, got %q", strings.Join(SessionMatches, ", "), cfg.Session.Match)
+	}
+
+	if !slices.Contains(Modes, cfg.UI.Mode) {
+		fail("ui.mode", "must be one of %s, got %q", strings.Join(Modes, ", "), cfg.UI.Mode)
+	}
+	if cfg.UI.InputHeight < 1 {
+		fail("ui.input_height", "must be at least 1, got %d", cfg.UI.InputHeight)
+	}
+	if cfg.UI.MaxOutputLines < 1 {
+		fail("ui.max_output_lines", "must be at least 1, got %d", cfg.UI.MaxOutputLines)
+	}
+
+	t := cfg.Theme
+	if !slices.Contains(BorderStyles, t.BorderStyle) {
+		fail("theme.border_style", "must be one of %s, got %q", strings.Join(BorderStyles, ", "), t.BorderStyle)
+	}
+	for key, color := range map[string]string{
+		"theme.output_border_color": t.OutputBorderColor,
+		"theme.input_border_color":  t.InputBorderColor,
+		"theme.status_color":        t.StatusColor,
+		"theme.thinking_color":      t.ThinkingColor,
+		"theme.tool_color":          t.ToolColor,
+		"theme.answer_color":        t.AnswerColor,
+	} {
+		if !validColor(color) {
+			fail(key, "must be a #rgb or #rrggbb hex color or an ANSI color number, got %q", color)
+		}
+	}
+
+	for action, keys := range cfg.Keys {
+		key := "keys." + action
+		switch {
+		case !slices.Contains(KeyActions, action):
+			fail(key, "unknown action (want one of %s)", strings.Join(KeyActions, ", "))
+		case len(keys) == 0 || slices.Contains(keys, ""):
+			fail(key, "must list at least one non-empty key")
+		}
+	}
+
+	if len(issues) == 0 {
+		return nil
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Key < issues[j].Key
+	})
+	return &ValidationError{Issues: issues}
+}
+
+// yamlLines walks the document against the yamlConfig schema, returning the
+// line of every known key (profile keys under "profiles.<name>.") and an
+// Issue for every unknown one.
+func yamlLines(file string, data []byte) (map[string]int, []Issue) {
+	lines := map[string]int{}
+	var unknown []Issue
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil || len(root.Content) == 0 {
+		return lines, nil
+	}
+	var walk func(n *yaml.Node, t reflect.Type, prefix string)
+	walk = func(n *yaml.Node, t reflect.Type, prefix string) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if n.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			key := prefix + k.Value
+			switch t.Kind() {
+			case reflect.Struct:
+				f, ok := fieldByTag(t, k.Value)
+				if !ok {
+					msg := "unknown key"
+					if s := suggest(t, k.Value); s != "" {
+						msg += fmt.Sprintf(" (did you mean %q?)", s)
+					}
+					unknown = append(unknown, Issue{File: file, Line: k.Line, Key: key, Source: SourceFile, Message: msg})
+					continue
+				}
+				lines[key] = k.Line
+				walk(v, f.Type, key+".")
+			case reflect.Map:
+				lines[key] = k.Line
+				walk(v, t.Elem(), key+".")
+			}
+		}
+	}
+	walk(root.Content[0], reflect.TypeOf(yamlConfig{}), "")
+	return lines, unknown
+}
+
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if strings.Split(f.Tag.Get("yaml"), ",")[0] == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// suggest returns the known key of t closest to name, if it is a likely typo.
+func suggest(t reflect.Type, name string) string {
+	best, bestDist := "", 3
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if d := editDistance(tag, name); d < bestDist {
+			best, bestDist = tag, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
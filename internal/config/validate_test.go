@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "miniopencode.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write yaml: %v", err)
+	}
+	return path
+}
+
+func TestLoadReportsInvalidValuesWithLines(t *testing.T) {
+	path := writeConfig(t, `server:
+  port: 0
+ui:
+  mode: fullscreen
+  input_height: -2
+theme:
+  status_color: "#12345"
+  tool_color: "212"
+`)
+	_, err := Load(path, Options{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	want := []string{
+		path + ":2: server.port: must be between 1 and 65535, got 0",
+		path + `:4: ui.mode: must be one of input, output, full, got "fullscreen"`,
+		path + ":5: ui.input_height: must be at least 1, got -2",
+		path + `:7: theme.status_color: must be a #rgb or #rrggbb hex color or an ANSI color number, got "#12345"`,
+	}
+	if len(verr.Issues) != len(want) {
+		t.Fatalf("expected %d issues, got %v", len(want), verr)
+	}
+	for i, w := range want {
+		if got := verr.Issues[i].Error(); got != w {
+			t.Errorf("issue %d:\n got  %s\n want %s", i, got, w)
+		}
+	}
+}
+
+func TestLoadWarnsOnUnknownKeys(t *testing.T) {
+	path := writeConfig(t, `ui:
+  show_thinkng: false
+serverr:
+  host: x
+profiles:
+  remote:
+    server:
+      hots: remote
+`)
+	cfg, report, err := LoadDetailed(path, Options{})
+	if err != nil {
+		t.Fatalf("unknown keys should not be fatal: %v", err)
+	}
+	if !cfg.UI.ShowThinking {
+		t.Fatalf("misspelled key must not change the value")
+	}
+	var got []string
+	for _, w := range report.Warnings {
+		got = append(got, w.Error())
+	}
+	want := []string{
+		path + `:2: ui.show_thinkng: unknown key (did you mean "show_thinking"?)`,
+		path + `:3: serverr: unknown key (did you mean "server"?)`,
+		path + `:8: profiles.remote.server.hots: unknown key (did you mean "host"?)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected warnings:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestValidationNamesEnvFlagAndProfile(t *testing.T) {
+	path := writeConfig(t, `profiles:
+  bad:
+    session:
+      daily_max_tokens: -1
+`)
+	t.Setenv("MINIOPENCODE_UI_MODE", "tiny")
+	_, err := Load(path, Options{Profile: strPtr("bad"), InputHeight: intPtr(0)})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	msg := verr.Error()
+	for _, want := range []string{
+		path + ":4: session.daily_max_tokens: must not be negative",
+		`$MINIOPENCODE_UI_MODE: ui.mode: must be one of`,
+		`command line: ui.input_height: must be at least 1`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in:\n%s", want, msg)
+		}
+	}
+}
+
+func TestLoadReportsYAMLSyntaxErrorsWithPath(t *testing.T) {
+	path := writeConfig(t, "server:\n  port: [\n")
+	if _, err := Load(path, Options{}); err == nil || !strings.HasPrefix(err.Error(), path+": ") {
+		t.Fatalf("expected error prefixed with the file path, got %v", err)
+	}
+}
+
+func TestLoadValidatesKeys(t *testing.T) {
+	path := writeConfig(t, `keys:
+  quit: [ctrl+q]
+  launch: [x]
+`)
+	_, err := Load(path, Options{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Issues) != 1 || verr.Issues[0].Key != "keys.launch" || verr.Issues[0].Line != 3 {
+		t.Fatalf("expected one issue for keys.launch on line 3, got %v", err)
+	}
+
+	path = writeConfig(t, "keys:\n  quit: [ctrl+q]\n")
+	cfg, err := Load(path, Options{})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got := cfg.Keys["quit"]; len(got) != 1 || got[0] != "ctrl+q" {
+		t.Fatalf("unexpected keys: %v", cfg.Keys)
+	}
+}
+
+func TestLoadValidatesTitleFormats(t *testing.T) {
+	path := writeConfig(t, `session:
+  title_formats:
+    weekly: "week-2006-01-02"
+    yearly: "2006-%d"
+    branch: "{branch}'s work %d"
+    monthly: "'sprint %d' 2006-01"
+    daily: "'v2' 2006-01-02 #%d"
+`)
+	_, err := Load(path, Options{})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Issues) != 3 {
+		t.Fatalf("expected three issues, got %v", err)
+	}
+	want := map[string]int{
+		"session.title_formats.weekly":  3,
+		"session.title_formats.yearly":  4,
+		"session.title_formats.monthly": 6,
+	}
+	for _, i := range verr.Issues {
+		if line, ok := want[i.Key]; !ok || i.Line != line {
+			t.Errorf("unexpected issue: %v", i)
+		}
+	}
+}
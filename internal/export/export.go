@@ -0,0 +1,227 @@
+// Package export renders a session's full server-side history as Markdown,
+// JSON or standalone HTML.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+// Format selects the export output format.
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatJSON     Format = "json"
+	FormatHTML     Format = "html"
+)
+
+// ParseFormat accepts a format name or common alias ("markdown", "htm").
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	case "json":
+		return FormatJSON, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (want md, json or html)", s)
+	}
+}
+
+// Ext returns the file extension for the format, including the dot.
+func (f Format) Ext() string {
+	return "." + string(f)
+}
+
+// HistoryClient is the subset of client.Client needed to export a session.
+type HistoryClient interface {
+	GetSession(ctx context.Context, sessionID string) (client.Session, error)
+	SessionMessages(ctx context.Context, sessionID string) ([]client.MessageWithParts, error)
+}
+
+// Document is the normalized, format-independent view of a session. It is
+// also the schema of the JSON export.
+type Document struct {
+	SessionID  string     `json:"session_id"`
+	Title      string     `json:"title"`
+	Created    *time.Time `json:"created,omitempty"`
+	Updated    *time.Time `json:"updated,omitempty"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Messages   []Message  `json:"messages"`
+}
+
+// Message is one user or assistant message of an exported session.
+type Message struct {
+	ID         string         `json:"id"`
+	Role       string         `json:"role"`
+	Created    *time.Time     `json:"created,omitempty"`
+	Agent      string         `json:"agent,omitempty"`
+	ProviderID string         `json:"provider_id,omitempty"`
+	ModelID    string         `json:"model_id,omitempty"`
+	Tokens     *client.Tokens `json:"tokens,omitempty"`
+	Cost       float64        `json:"cost,omitempty"`
+	Parts      []Part         `json:"parts"`
+}
+
+// Part is a text, reasoning or tool part of a message.
+type Part struct {
+	Type   string         `json:"type"`
+	Text   string         `json:"text,omitempty"`
+	Tool   string         `json:"tool,omitempty"`
+	Status string         `json:"status,omitempty"`
+	Title  string         `json:"title,omitempty"`
+	Input  map[string]any `json:"input,omitempty"`
+	Output string         `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// Load fetches the session and its complete message history from the server.
+func Load(ctx context.Context, c HistoryClient, sessionID string) (Document, error) {
+	session, err := c.GetSession(ctx, sessionID)
+	if err != nil {
+		return Document{}, fmt.Errorf("load session: %w", err)
+	}
+	msgs, err := c.SessionMessages(ctx, sessionID)
+	if err != nil {
+		return Document{}, fmt.Errorf("load messages: %w", err)
+	}
+	return newDocument(session, msgs, time.Now()), nil
+}
+
+func newDocument(session client.Session, msgs []client.MessageWithParts, now time.Time) Document {
+	doc := Document{
+		SessionID:  session.ID,
+		Title:      session.Title,
+		ExportedAt: now.UTC(),
+		Messages:   make([]Message, 0, len(msgs)),
+	}
+	if session.Time != nil {
+		doc.Created = msTime(session.Time.Created)
+		doc.Updated = msTime(session.Time.Updated)
+	}
+	for _, m := range msgs {
+		msg := Message{
+			ID:         m.Info.ID,
+			Role:       m.Info.Role,
+			Agent:      m.Info.Agent,
+			ProviderID: m.Info.ProviderID,
+			ModelID:    m.Info.ModelID,
+			Tokens:     m.Info.Tokens,
+			Cost:       m.Info.Cost,
+		}
+		if m.Info.Time != nil {
+			msg.Created = msTime(m.Info.Time.Created)
+		}
+		for _, p := range m.Parts {
+			switch p.PartType {
+			case "text", "reasoning":
+				if strings.TrimSpace(p.Text) == "" {
+					continue
+				}
+				msg.Parts = append(msg.Parts, Part{Type: p.PartType, Text: p.Text})
+			case "tool":
+				part := Part{Type: "tool", Tool: p.Tool}
+				if p.State != nil {
+					part.Status = p.State.Status
+					part.Title = p.State.Title
+					part.Input = p.State.Input
+					part.Output = p.State.Output
+					part.Error = p.State.Error
+				}
+				msg.Parts = append(msg.Parts, part)
+			}
+		}
+		doc.Messages = append(doc.Messages, msg)
+	}
+	return doc
+}
+
+// Write renders doc to w in the given format.
+func Write(w io.Writer, doc Document, f Format) error {
+	switch f {
+	case FormatMarkdown:
+		return writeMarkdown(w, doc)
+	case FormatJSON:
+		return writeJSON(w, doc)
+	case FormatHTML:
+		return writeHTML(w, doc)
+	default:
+		return fmt.Errorf("unknown export format %q", f)
+	}
+}
+
+// WriteFile renders doc to the file at path, replacing it if it exists.
+func WriteFile(path string, doc Document, f Format) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, doc, f); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// msTime converts an epoch-milliseconds timestamp, returning nil for zero.
+func msTime(ms float64) *time.Time {
+	if ms == 0 {
+		return nil
+	}
+	t := time.UnixMilli(int64(ms)).UTC()
+	return &t
+}
+
+func (m Message) heading() string {
+	if m.Role != "assistant" {
+		return "User"
+	}
+	var details []string
+	if m.Agent != "" {
+		details = append(details, m.Agent)
+	}
+	if m.ModelID != "" {
+		model := m.ModelID
+		if m.ProviderID != "" {
+			model = m.ProviderID + "/" + m.ModelID
+		}
+		details = append(details, model)
+	}
+	if len(details) == 0 {
+		return "Assistant"
+	}
+	return "Assistant (" + strings.Join(details, ", ") + ")"
+}
+
+func (m Message) usage() string {
+	if m.Tokens == nil && m.Cost == 0 {
+		return ""
+	}
+	var fields []string
+	if m.Tokens != nil {
+		fields = append(fields, fmt.Sprintf("tokens: %d in / %d out / %d reasoning", m.Tokens.Input, m.Tokens.Output, m.Tokens.Reasoning))
+	}
+	if m.Cost != 0 {
+		fields = append(fields, fmt.Sprintf("cost: $%.4f", m.Cost))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func (p Part) summary() string {
+	s := "Tool: " + p.Tool
+	if p.Title != "" {
+		s += " — " + p.Title
+	}
+	if p.Status != "" {
+		s += " (" + p.Status + ")"
+	}
+	return s
+}
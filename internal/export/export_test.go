@@ -0,0 +1,146 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"miniopencode/internal/client"
+)
+
+type stubHistory struct {
+	session client.Session
+	msgs    []client.MessageWithParts
+}
+
+func (s stubHistory) GetSession(ctx context.Context, sessionID string) (client.Session, error) {
+	return s.session, nil
+}
+
+func (s stubHistory) SessionMessages(ctx context.Context, sessionID string) ([]client.MessageWithParts, error) {
+	return s.msgs, nil
+}
+
+func sampleHistory() stubHistory {
+	return stubHistory{
+		session: client.Session{ID: "ses-1", Title: "Release notes", Time: &client.SessionTime{Created: 1760000000000, Updated: 1760000600000}},
+		msgs: []client.MessageWithParts{
+			{
+				Info:  client.MessageInfo{ID: "msg-1", Role: "user"},
+				Parts: []client.Part{{ID: "p1", PartType: "text", Text: "Summarize <script>alert(1)</script>"}},
+			},
+			{
+				Info: client.MessageInfo{ID: "msg-2", Role: "assistant", Agent: "build", ProviderID: "anthropic", ModelID: "claude", Cost: 0.0123, Tokens: &client.Tokens{Input: 10, Output: 20}},
+				Parts: []client.Part{
+					{ID: "p2", PartType: "step-start"},
+					{ID: "p3", PartType: "reasoning", Text: "Let me look at the log."},
+					{ID: "p4", PartType: "tool", Tool: "bash", State: &client.ToolState{Status: "completed", Input: map[string]any{"command": "git log"}, Output: "abc123 fix ```weird``` output"}},
+					{ID: "p5", PartType: "text", Text: "Here is the **summary**."},
+				},
+			},
+		},
+	}
+}
+
+func loadSample(t *testing.T) Document {
+	t.Helper()
+	doc, err := Load(context.Background(), sampleHistory(), "ses-1")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return doc
+}
+
+func TestLoadNormalizesParts(t *testing.T) {
+	doc := loadSample(t)
+	if doc.Title != "Release notes" || doc.Created == nil || len(doc.Messages) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	parts := doc.Messages[1].Parts
+	if len(parts) != 3 {
+		t.Fatalf("expected step-start to be dropped, got %+v", parts)
+	}
+	if parts[1].Type != "tool" || parts[1].Status != "completed" || parts[1].Input["command"] != "git log" {
+		t.Fatalf("unexpected tool part: %+v", parts[1])
+	}
+}
+
+func TestWriteMarkdownCollapsesThinkingAndTools(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, loadSample(t), FormatMarkdown); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# Release notes",
+		"## Assistant (build, anthropic/claude)",
+		"<summary>Thinking</summary>",
+		"<summary>Tool: bash (completed)</summary>",
+		"````\nabc123 fix ```weird``` output\n````",
+		"Here is the **summary**.",
+		"cost: $0.0123",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteMarkdownEscapesToolSummary(t *testing.T) {
+	doc := Document{SessionID: "ses-1", Messages: []Message{{Role: "assistant", Parts: []Part{
+		{Type: "tool", Tool: "edit", Title: "fix </details><b>x</b>", Status: "completed"},
+	}}}}
+	var buf bytes.Buffer
+	if err := Write(&buf, doc, FormatMarkdown); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "<summary>Tool: edit — fix &lt;/details&gt;&lt;b&gt;x&lt;/b&gt; (completed)</summary>") {
+		t.Fatalf("tool summary must be HTML-escaped:\n%s", out)
+	}
+	if strings.Count(out, "</details>") != 1 {
+		t.Fatalf("tool title must not close the details block:\n%s", out)
+	}
+}
+
+func TestWriteJSONRoundTrips(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, loadSample(t), FormatJSON); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	var doc Document
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if doc.SessionID != "ses-1" || len(doc.Messages) != 2 || doc.Messages[1].Tokens.Output != 20 {
+		t.Fatalf("unexpected round trip: %+v", doc)
+	}
+}
+
+func TestWriteHTMLEscapesContent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, loadSample(t), FormatHTML); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "<script>alert(1)</script>") {
+		t.Fatalf("raw HTML from messages must not be emitted")
+	}
+	if !strings.Contains(out, "<strong>summary</strong>") || !strings.Contains(out, `<details class="tool">`) {
+		t.Fatalf("expected rendered markdown and tool details:\n%s", out)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"md": FormatMarkdown, "Markdown": FormatMarkdown, "json": FormatJSON, "html": FormatHTML} {
+		got, err := ParseFormat(in)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Errorf("expected error for unknown format")
+	}
+}
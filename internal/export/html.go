@@ -0,0 +1,108 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdownHTML renders answer markdown to HTML. Raw HTML in the source is
+// dropped (goldmark's default), so exported pages cannot inject markup.
+var markdownHTML = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"markdown": renderHTMLMarkdown,
+	"json": func(v any) string {
+		b, _ := json.MarshalIndent(v, "", "  ")
+		return string(b)
+	},
+	"timestamp": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{if .Title}}{{.Title}}{{else}}{{.SessionID}}{{end}}</title>
+<style>
+body { max-width: 52rem; margin: 2rem auto; padding: 0 1rem; font: 15px/1.55 system-ui, sans-serif; color: #1e1e2e; background: #fff; }
+header { border-bottom: 1px solid #ddd; margin-bottom: 1.5rem; }
+.meta { color: #6c7086; font-size: 0.85rem; }
+.message { margin: 1.5rem 0; padding: 0.75rem 1rem; border-left: 4px solid #89b4fa; background: #f7f8fc; }
+.message.user { border-color: #a6e3a1; background: #f6fbf5; }
+.message h2 { font-size: 1rem; margin: 0 0 0.5rem; }
+details { margin: 0.5rem 0; padding: 0.25rem 0.75rem; background: #fff; border: 1px solid #e3e3ea; border-radius: 4px; }
+details.thinking summary { color: #b58900; }
+details.tool summary { color: #2aa198; }
+pre { overflow-x: auto; padding: 0.75rem; background: #1e1e2e; color: #cdd6f4; border-radius: 4px; }
+code { font-family: ui-monospace, monospace; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{if .Title}}{{.Title}}{{else}}{{.SessionID}}{{end}}</h1>
+<p class="meta">Session <code>{{.SessionID}}</code>{{with timestamp .Created}} · created {{.}}{{end}}{{with timestamp .Updated}} · updated {{.}}{{end}} · exported {{.ExportedAt.Format "2006-01-02T15:04:05Z07:00"}}</p>
+</header>
+{{range .Messages}}
+<section class="message {{.Role}}">
+<h2>{{.Heading}}</h2>
+{{with timestamp .Created}}<p class="meta">{{.}}</p>{{end}}
+{{range .Parts}}
+{{if eq .Type "reasoning"}}<details class="thinking"><summary>Thinking</summary>{{markdown .Text}}</details>
+{{else if eq .Type "tool"}}<details class="tool"><summary>{{.Summary}}</summary>
+{{if .Input}}<p><strong>Input</strong></p><pre><code>{{json .Input}}</code></pre>{{end}}
+{{if .Output}}<p><strong>Output</strong></p><pre><code>{{.Output}}</code></pre>{{end}}
+{{if .Error}}<p><strong>Error</strong></p><pre><code>{{.Error}}</code></pre>{{end}}
+</details>
+{{else}}{{markdown .Text}}
+{{end}}
+{{end}}
+{{with .Usage}}<p class="meta">{{.}}</p>{{end}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+type htmlMessage struct {
+	Message
+	Heading string
+	Usage   string
+	Parts   []htmlPart
+}
+
+type htmlPart struct {
+	Part
+	Summary string
+}
+
+func writeHTML(w io.Writer, doc Document) error {
+	view := struct {
+		Document
+		Messages []htmlMessage
+	}{Document: doc}
+	for _, m := range doc.Messages {
+		hm := htmlMessage{Message: m, Heading: m.heading(), Usage: m.usage()}
+		for _, p := range m.Parts {
+			hm.Parts = append(hm.Parts, htmlPart{Part: p, Summary: p.summary()})
+		}
+		view.Messages = append(view.Messages, hm)
+	}
+	return htmlTemplate.Execute(w, view)
+}
+
+func renderHTMLMarkdown(md string) template.HTML {
+	var buf bytes.Buffer
+	if err := markdownHTML.Convert([]byte(md), &buf); err != nil {
+		return template.HTML("<pre>" + template.HTMLEscapeString(md) + "</pre>")
+	}
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,12 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func writeJSON(w io.Writer, doc Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
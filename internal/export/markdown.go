@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// writeMarkdown renders doc as Markdown. Thinking and tool calls are wrapped
+// in <details> blocks so they stay collapsed in wikis and ticket trackers.
+func writeMarkdown(w io.Writer, doc Document) error {
+	bw := bufio.NewWriter(w)
+
+	title := doc.Title
+	if title == "" {
+		title = doc.SessionID
+	}
+	fmt.Fprintf(bw, "# %s\n\n", title)
+	fmt.Fprintf(bw, "- Session: `%s`\n", doc.SessionID)
+	if doc.Created != nil {
+		fmt.Fprintf(bw, "- Created: %s\n", doc.Created.Format(time.RFC3339))
+	}
+	if doc.Updated != nil {
+		fmt.Fprintf(bw, "- Updated: %s\n", doc.Updated.Format(time.RFC3339))
+	}
+	fmt.Fprintf(bw, "- Exported: %s\n", doc.ExportedAt.Format(time.RFC3339))
+
+	for _, m := range doc.Messages {
+		fmt.Fprintf(bw, "\n## %s\n", m.heading())
+		if m.Created != nil {
+			fmt.Fprintf(bw, "\n_%s_\n", m.Created.Format(time.RFC3339))
+		}
+		writeMarkdownBody(bw, m)
+	}
+	return bw.Flush()
+}
+
+// WriteMarkdownMessage renders the parts and usage of a single message as
+// Markdown, without a heading, for output outside a session document.
+func WriteMarkdownMessage(w io.Writer, m Message) error {
+	bw := bufio.NewWriter(w)
+	writeMarkdownBody(bw, m)
+	return bw.Flush()
+}
+
+func writeMarkdownBody(bw *bufio.Writer, m Message) {
+	for _, p := range m.Parts {
+		bw.WriteString("\n")
+		switch p.Type {
+		case "reasoning":
+			fmt.Fprintf(bw, "<details>\n<summary>Thinking</summary>\n\n%s\n\n</details>\n", strings.TrimSpace(p.Text))
+		case "tool":
+			fmt.Fprintf(bw, "<details>\n<summary>%s</summary>\n", html.EscapeString(p.summary()))
+			if len(p.Input) > 0 {
+				input, _ := json.MarshalIndent(p.Input, "", "  ")
+				fmt.Fprintf(bw, "\n**Input**\n\n%s\n", fenced("json", string(input)))
+			}
+			if p.Output != "" {
+				fmt.Fprintf(bw, "\n**Output**\n\n%s\n", fenced("", p.Output))
+			}
+			if p.Error != "" {
+				fmt.Fprintf(bw, "\n**Error**\n\n%s\n", fenced("", p.Error))
+			}
+			bw.WriteString("\n</details>\n")
+		default:
+			fmt.Fprintf(bw, "%s\n", strings.TrimSpace(p.Text))
+		}
+	}
+	if usage := m.usage(); usage != "" {
+		fmt.Fprintf(bw, "\n_%s_\n", usage)
+	}
+}
+
+// fenced wraps text in a code fence longer than any backtick run inside it.
+func fenced(lang, text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence
+}
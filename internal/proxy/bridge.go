@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang.org/x/net/websocket"
+
+	"miniopencode/internal/client"
+)
+
+// DefaultAllowedOrigins are the browser origins a Bridge accepts when none
+// are configured: pages served from the loopback interface.
+var DefaultAllowedOrigins = []string{
+	"http://localhost", "http://localhost:*",
+	"http://127.0.0.1", "http://127.0.0.1:*",
+}
+
+// BridgeOptions configures access to a Bridge.
+type BridgeOptions struct {
+	// Token, if set, must be presented as "Authorization: Bearer <token>" or,
+	// for browsers that cannot set headers on WebSockets, as ?token=<token>.
+	Token string
+	// AllowedOrigins are path.Match patterns for the Origin header, such as
+	// "vscode-webview://*". Requests without an Origin (non-browser clients)
+	// are always accepted. Defaults to DefaultAllowedOrigins.
+	AllowedOrigins []string
+}
+
+// Bridge exposes the headless command set over HTTP for clients that cannot
+// spawn a stdio process: a WebSocket at /ws speaking the configured protocol
+// (one message per frame), and a small REST surface. Both run commands
+// through the same dispatch as --headless and share one client and one
+// upstream SSE connection.
+type Bridge struct {
+	config  Config
+	client  *client.Client
+	hub     *hub
+	options BridgeOptions
+	mux     *http.ServeMux
+}
+
+// NewBridge builds a Bridge for the opencode server in config.
+func NewBridge(config Config, options BridgeOptions) (*Bridge, error) {
+	if err := checkCodec(config.Protocol, FramingLine); err != nil {
+		return nil, err
+	}
+	if len(options.AllowedOrigins) == 0 {
+		options.AllowedOrigins = DefaultAllowedOrigins
+	}
+	cli := newClient(&config)
+	b := &Bridge{
+		config:  config,
+		client:  cli,
+		hub:     newHub(cli.EventStream),
+		options: options,
+		mux:     http.NewServeMux(),
+	}
+
+	ws := websocket.Server{
+		// Origin and token are checked in ServeHTTP before the upgrade.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler:   b.serveWebSocket,
+	}
+	b.mux.Handle("GET /ws", ws)
+	b.mux.HandleFunc("GET /health", b.rest("health", ""))
+	b.mux.HandleFunc("GET /sessions", b.rest("session.list", ""))
+	b.mux.HandleFunc("POST /sessions", b.rest("session.create", ""))
+	b.mux.HandleFunc("POST /sessions/{id}/prompt", b.rest("prompt", "id"))
+	b.mux.HandleFunc("POST /sessions/{id}/prompt/wait", b.rest("prompt.wait", "id"))
+	return b, nil
+}
+
+// ServeHTTP checks the request's origin and token, then routes it.
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && !b.originAllowed(origin) {
+		log.Printf("bridge: rejected origin %q", origin)
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	if !b.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "missing or invalid token", http.StatusUnauthorized)
+		return
+	}
+	b.mux.ServeHTTP(w, r)
+}
+
+func (b *Bridge) originAllowed(origin string) bool {
+	for _, pattern := range b.options.AllowedOrigins {
+		if ok, _ := path.Match(pattern, origin); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bridge) authorized(r *http.Request) bool {
+	if b.options.Token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		token, _ = strings.CutPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(b.options.Token)) == 1
+}
+
+// serveWebSocket runs one headless session over a WebSocket connection.
+func (b *Bridge) serveWebSocket(ws *websocket.Conn) {
+	defer ws.Close()
+	ws.MaxPayloadBytes = maxMessageSize
+	f := &wsFramer{conn: ws}
+	var c codec = &jsonCodec{framer: f}
+	if b.config.Protocol == ProtocolJSONRPC {
+		c = &rpcCodec{framer: f}
+	}
+	log.Printf("bridge: websocket from %s", ws.Request().RemoteAddr)
+	newProxy(b.config, b.client, b.hub).serve(c)
+}
+
+// rest returns a handler running cmdType with the request body as payload.
+// When sessionParam is set, the session is taken from that path parameter.
+func (b *Bridge) rest(cmdType, sessionParam string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+		if err != nil {
+			writeREST(w, http.StatusBadRequest, Response{Type: "error", Data: map[string]string{"message": err.Error()}})
+			return
+		}
+		if len(strings.TrimSpace(string(payload))) == 0 {
+			payload = []byte("{}")
+		}
+		config := b.config
+		if sessionParam != "" {
+			config.SessionID = r.PathValue(sessionParam)
+		}
+		p := newProxy(config, b.client, b.hub)
+		cmd := Command{Type: cmdType, Payload: payload}
+
+		replyType, data, err := p.handleCommand(r.Context(), cmd)
+		if err != nil {
+			writeREST(w, restStatus(err), Response{Type: "error", Data: map[string]string{
+				"message": err.Error(),
+				"command": cmdType,
+			}})
+			return
+		}
+		writeREST(w, http.StatusOK, Response{Type: replyType, Data: data})
+	}
+}
+
+func restStatus(err error) int {
+	var pe *payloadError
+	switch {
+	case errors.As(err, &pe), errors.Is(err, errNoSession):
+		return http.StatusBadRequest
+	case errors.Is(err, errUnknownCommand):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeREST(w http.ResponseWriter, status int, r Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(r)
+}
+
+// wsFramer carries one message per WebSocket text frame.
+type wsFramer struct {
+	conn *websocket.Conn
+}
+
+func (f *wsFramer) ReadMessage() ([]byte, error) {
+	var msg []byte
+	if err := websocket.Message.Receive(f.conn, &msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (f *wsFramer) WriteMessage(b []byte) error {
+	return websocket.Message.Send(f.conn, string(b))
+}
@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func newTestBridge(t *testing.T, options BridgeOptions) *httptest.Server {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/global/health":
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/session" && r.Method == http.MethodGet:
+			io.WriteString(w, `[{"id":"ses-1","title":"one"}]`)
+		case strings.HasSuffix(r.URL.Path, "/prompt_async"):
+			if r.URL.Path != "/session/ses-1/prompt_async" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	b, err := NewBridge(Config{BaseURLOverride: upstream.URL}, options)
+	if err != nil {
+		t.Fatalf("bridge: %v", err)
+	}
+	srv := httptest.NewServer(b)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func restCall(t *testing.T, method, url, body string, header http.Header) (int, Response) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	var r Response
+	if resp.Header.Get("Content-Type") == "application/json" {
+		json.NewDecoder(resp.Body).Decode(&r)
+	}
+	return resp.StatusCode, r
+}
+
+func TestBridgeREST(t *testing.T) {
+	srv := newTestBridge(t, BridgeOptions{})
+
+	if status, r := restCall(t, "GET", srv.URL+"/health", "", nil); status != 200 || r.Data.(map[string]any)["healthy"] != true {
+		t.Fatalf("health: %d %+v", status, r)
+	}
+	if status, r := restCall(t, "GET", srv.URL+"/sessions", "", nil); status != 200 || r.Type != "session.list" || len(r.Data.([]any)) != 1 {
+		t.Fatalf("sessions: %d %+v", status, r)
+	}
+	if status, r := restCall(t, "POST", srv.URL+"/sessions/ses-1/prompt", `{"text":"hi"}`, nil); status != 200 || r.Type != "prompt.sent" {
+		t.Fatalf("prompt: %d %+v", status, r)
+	}
+	if status, r := restCall(t, "POST", srv.URL+"/sessions/ses-1/prompt", `"bad"`, nil); status != 400 || r.Type != "error" {
+		t.Fatalf("bad payload: %d %+v", status, r)
+	}
+	if status, _ := restCall(t, "POST", srv.URL+"/sessions/ses-x/prompt", `{"text":"hi"}`, nil); status != http.StatusBadGateway {
+		t.Fatalf("upstream failure should be 502, got %d", status)
+	}
+}
+
+func TestBridgeChecksOriginAndToken(t *testing.T) {
+	srv := newTestBridge(t, BridgeOptions{Token: "s3cret", AllowedOrigins: []string{"vscode-webview://*"}})
+
+	tests := []struct {
+		name   string
+		url    string
+		header http.Header
+		want   int
+	}{
+		{"no token", "/health", nil, http.StatusUnauthorized},
+		{"wrong token", "/health", http.Header{"Authorization": {"Bearer nope"}}, http.StatusUnauthorized},
+		{"bearer", "/health", http.Header{"Authorization": {"Bearer s3cret"}}, http.StatusOK},
+		{"query", "/health?token=s3cret", nil, http.StatusOK},
+		{"allowed origin", "/health?token=s3cret", http.Header{"Origin": {"vscode-webview://abc"}}, http.StatusOK},
+		{"foreign origin", "/health?token=s3cret", http.Header{"Origin": {"https://evil.example"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if status, _ := restCall(t, "GET", srv.URL+tt.url, "", tt.header); status != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, status)
+		}
+	}
+}
+
+func TestBridgeWebSocket(t *testing.T) {
+	srv := newTestBridge(t, BridgeOptions{})
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+
+	ws, err := websocket.Dial(wsURL, "", "http://localhost:3000")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	read := func() Response {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatalf("receive: %v", err)
+		}
+		var r Response
+		if err := json.Unmarshal([]byte(msg), &r); err != nil {
+			t.Fatalf("invalid message %q: %v", msg, err)
+		}
+		return r
+	}
+	if r := read(); r.Type != "ready" {
+		t.Fatalf("expected ready, got %+v", r)
+	}
+	websocket.Message.Send(ws, `{"id":1,"type":"session.select","payload":{"id":"ses-1"}}`)
+	if r := read(); r.Type != "session.selected" || string(r.ID) != "1" {
+		t.Fatalf("unexpected reply: %+v", r)
+	}
+	websocket.Message.Send(ws, `{"id":2,"type":"prompt","payload":{"text":"hi"}}`)
+	if r := read(); r.Type != "prompt.sent" || string(r.ID) != "2" {
+		t.Fatalf("unexpected reply: %+v", r)
+	}
+
+	if _, err := websocket.Dial(wsURL, "", "https://evil.example"); err == nil {
+		t.Fatalf("websocket from a foreign origin must be rejected")
+	}
+}
@@ -0,0 +1,194 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Protocols and framings accepted by Config.
+const (
+	ProtocolJSON    = "json"
+	ProtocolJSONRPC = "jsonrpc"
+
+	FramingLine          = "line"
+	FramingContentLength = "content-length"
+)
+
+const maxMessageSize = 1024 * 1024
+
+// codec reads commands from and writes replies to a headless transport.
+// Calls to the Write methods are serialized by the Proxy.
+type codec interface {
+	// ReadCommand returns the next command. A *decodeError reports a
+	// malformed message that can be answered and skipped; any other error
+	// (io.EOF included) ends the session.
+	ReadCommand() (Command, error)
+	WriteReply(cmd Command, replyType string, data any) error
+	WriteError(cmd Command, err error) error
+	WriteEvent(eventType string, data any) error
+}
+
+// decodeError is a message that could not be turned into a Command.
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string { return e.err.Error() }
+func (e *decodeError) Unwrap() error { return e.err }
+
+// checkCodec reports whether protocol and framing are supported.
+func checkCodec(protocol, framing string) error {
+	switch framing {
+	case "", FramingLine, FramingContentLength:
+	default:
+		return fmt.Errorf("unknown framing %q (want %s or %s)", framing, FramingLine, FramingContentLength)
+	}
+	switch protocol {
+	case "", ProtocolJSON, ProtocolJSONRPC:
+	default:
+		return fmt.Errorf("unknown protocol %q (want %s or %s)", protocol, ProtocolJSON, ProtocolJSONRPC)
+	}
+	return nil
+}
+
+// newCodec builds the codec for a protocol and framing over r and w.
+func newCodec(protocol, framing string, r io.Reader, w io.Writer) (codec, error) {
+	if err := checkCodec(protocol, framing); err != nil {
+		return nil, err
+	}
+	var f framer = newLineFramer(r, w)
+	if framing == FramingContentLength {
+		f = newContentLengthFramer(r, w)
+	}
+	if protocol == ProtocolJSONRPC {
+		return &rpcCodec{framer: f}, nil
+	}
+	return &jsonCodec{framer: f}, nil
+}
+
+// framer splits a byte stream into messages.
+type framer interface {
+	ReadMessage() ([]byte, error)
+	WriteMessage(b []byte) error
+}
+
+// lineFramer carries one JSON message per line.
+type lineFramer struct {
+	scanner *bufio.Scanner
+	w       io.Writer
+}
+
+func newLineFramer(r io.Reader, w io.Writer) *lineFramer {
+	scanner := bufio.NewScanner(r)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, maxMessageSize)
+	return &lineFramer{scanner: scanner, w: w}
+}
+
+func (f *lineFramer) ReadMessage() ([]byte, error) {
+	for f.scanner.Scan() {
+		line := bytes.TrimSpace(f.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		return line, nil
+	}
+	if err := f.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (f *lineFramer) WriteMessage(b []byte) error {
+	_, err := fmt.Fprintln(f.w, string(b))
+	return err
+}
+
+// contentLengthFramer uses LSP-style "Content-Length: N\r\n\r\n" headers, so
+// messages may contain newlines and existing JSON-RPC libraries work as-is.
+type contentLengthFramer struct {
+	r *textproto.Reader
+	w io.Writer
+}
+
+func newContentLengthFramer(r io.Reader, w io.Writer) *contentLengthFramer {
+	return &contentLengthFramer{r: textproto.NewReader(bufio.NewReader(r)), w: w}
+}
+
+func (f *contentLengthFramer) ReadMessage() ([]byte, error) {
+	header, err := f.r.ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(header) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds limit of %d", length, maxMessageSize)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(f.r.R, body); err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	return body, nil
+}
+
+func (f *contentLengthFramer) WriteMessage(b []byte) error {
+	if _, err := fmt.Fprintf(f.w, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err := f.w.Write(b)
+	return err
+}
+
+// jsonCodec speaks the native {type, id, payload} / {type, id, data} protocol.
+type jsonCodec struct {
+	framer framer
+}
+
+func (c *jsonCodec) ReadCommand() (Command, error) {
+	msg, err := c.framer.ReadMessage()
+	if err != nil {
+		return Command{}, err
+	}
+	var cmd Command
+	if err := json.Unmarshal(msg, &cmd); err != nil {
+		return Command{}, &decodeError{fmt.Errorf("invalid JSON: %v", err)}
+	}
+	return cmd, nil
+}
+
+func (c *jsonCodec) WriteReply(cmd Command, replyType string, data any) error {
+	return c.write(Response{Type: replyType, ID: cmd.ID, Data: data})
+}
+
+func (c *jsonCodec) WriteError(cmd Command, err error) error {
+	data := map[string]string{"message": err.Error()}
+	if cmd.Type != "" {
+		data["command"] = cmd.Type
+	}
+	return c.write(Response{Type: "error", ID: cmd.ID, Data: data})
+}
+
+func (c *jsonCodec) WriteEvent(eventType string, data any) error {
+	return c.write(Response{Type: eventType, Data: data})
+}
+
+func (c *jsonCodec) write(r Response) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return c.framer.WriteMessage(b)
+}
@@ -0,0 +1,167 @@
+package proxy
+
+import (
+	"miniopencode/internal/client"
+)
+
+// Normalized event types emitted while SSE is running. They spare scripts
+// from reimplementing the delta/set rules of docs/sse-event-spec.md.
+const (
+	EventMessageStarted   = "message.started"
+	EventMessageCompleted = "message.completed"
+	EventPartDelta        = "part.delta"
+	EventPartSet          = "part.set"
+	EventToolState        = "tool.state"
+	EventRaw              = "sse"
+)
+
+// MessageEvent is the data of message.started and message.completed. Tokens
+// and Cost are only final on message.completed.
+type MessageEvent struct {
+	SessionID  string         `json:"session_id"`
+	MessageID  string         `json:"message_id"`
+	Agent      string         `json:"agent,omitempty"`
+	ProviderID string         `json:"provider_id,omitempty"`
+	ModelID    string         `json:"model_id,omitempty"`
+	Tokens     *client.Tokens `json:"tokens,omitempty"`
+	Cost       float64        `json:"cost,omitempty"`
+}
+
+// PartEvent is the data of part.delta (Text is appended to the part) and
+// part.set (Text replaces the part). Kind is "text" or "reasoning".
+type PartEvent struct {
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	PartID    string `json:"part_id"`
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+}
+
+// ToolEvent is the data of tool.state, sent whenever a tool call changes
+// status or title.
+type ToolEvent struct {
+	SessionID string         `json:"session_id"`
+	MessageID string         `json:"message_id"`
+	PartID    string         `json:"part_id"`
+	CallID    string         `json:"call_id,omitempty"`
+	Tool      string         `json:"tool"`
+	Status    string         `json:"status"`
+	Title     string         `json:"title,omitempty"`
+	Input     map[string]any `json:"input,omitempty"`
+	Output    string         `json:"output,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+type event struct {
+	Type string
+	Data any
+}
+
+// normalizer turns parsed SSE events into normalized events for assistant
+// messages. It keeps per-stream state, so use one per SSE connection.
+type normalizer struct {
+	roles     map[string]string
+	started   map[string]bool
+	completed map[string]bool
+	texts     map[string]string
+	tools     map[string]string
+}
+
+func newNormalizer() *normalizer {
+	return &normalizer{
+		roles:     make(map[string]string),
+		started:   make(map[string]bool),
+		completed: make(map[string]bool),
+		texts:     make(map[string]string),
+		tools:     make(map[string]string),
+	}
+}
+
+func (n *normalizer) normalize(parsed any) []event {
+	switch e := parsed.(type) {
+	case *client.MessageUpdatedEvent:
+		return n.message(e.Properties.Info)
+	case *client.MessagePartUpdatedEvent:
+		if n.roles[e.Properties.Part.MessageID] == "user" {
+			return nil
+		}
+		return n.part(e)
+	default:
+		return nil
+	}
+}
+
+func (n *normalizer) message(info client.MessageInfo) []event {
+	n.roles[info.ID] = info.Role
+	if info.Role != "assistant" {
+		return nil
+	}
+	data := MessageEvent{
+		SessionID:  info.SessionID,
+		MessageID:  info.ID,
+		Agent:      info.Agent,
+		ProviderID: info.ProviderID,
+		ModelID:    info.ModelID,
+	}
+	var events []event
+	if !n.started[info.ID] {
+		n.started[info.ID] = true
+		events = append(events, event{EventMessageStarted, data})
+	}
+	if info.IsComplete() && !n.completed[info.ID] {
+		n.completed[info.ID] = true
+		data.Tokens = info.Tokens
+		data.Cost = info.Cost
+		events = append(events, event{EventMessageCompleted, data})
+	}
+	return events
+}
+
+func (n *normalizer) part(e *client.MessagePartUpdatedEvent) []event {
+	part := e.Properties.Part
+	update := e.ToStreamUpdate()
+	switch update.Kind {
+	case client.PartKindText, client.PartKindReasoning:
+		data := PartEvent{
+			SessionID: part.SessionID,
+			MessageID: part.MessageID,
+			PartID:    part.ID,
+			Kind:      string(update.Kind),
+			Text:      update.Text,
+		}
+		if update.Op == client.OpAppend {
+			n.texts[part.ID] += update.Text
+			return []event{{EventPartDelta, data}}
+		}
+		if n.texts[part.ID] == update.Text {
+			return nil
+		}
+		n.texts[part.ID] = update.Text
+		return []event{{EventPartSet, data}}
+
+	case client.PartKindTool:
+		if part.State == nil {
+			return nil
+		}
+		key := part.State.Status + "\x00" + part.State.Title
+		if n.tools[part.ID] == key {
+			return nil
+		}
+		n.tools[part.ID] = key
+		return []event{{EventToolState, ToolEvent{
+			SessionID: part.SessionID,
+			MessageID: part.MessageID,
+			PartID:    part.ID,
+			CallID:    part.CallID,
+			Tool:      part.Tool,
+			Status:    part.State.Status,
+			Title:     part.State.Title,
+			Input:     part.State.Input,
+			Output:    part.State.Output,
+			Error:     part.State.Error,
+		}}}
+
+	default:
+		return nil
+	}
+}
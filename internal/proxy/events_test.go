@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var streamEvents = []string{
+	`{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"s1","role":"user"}}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"up","sessionID":"s1","messageID":"u1","type":"text","text":"question"}}}`,
+	`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","agent":"build","providerID":"p","modelID":"m"}}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"t1","sessionID":"s1","messageID":"a1","type":"text","text":"Hel"},"delta":"Hel"}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"t1","sessionID":"s1","messageID":"a1","type":"text","text":"Hello"},"delta":"lo"}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"t1","sessionID":"s1","messageID":"a1","type":"text","text":"Hello"}}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"t1","sessionID":"s1","messageID":"a1","type":"text","text":"Hello!"}}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"x1","sessionID":"s1","messageID":"a1","type":"tool","tool":"bash","callID":"c1","state":{"status":"running","input":{"command":"ls"}}}}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"x1","sessionID":"s1","messageID":"a1","type":"tool","tool":"bash","callID":"c1","state":{"status":"running","input":{"command":"ls"}}}}}`,
+	`{"type":"message.part.updated","properties":{"part":{"id":"x1","sessionID":"s1","messageID":"a1","type":"tool","tool":"bash","callID":"c1","state":{"status":"completed","output":"a.go"}}}}`,
+	`{"type":"session.idle","properties":{"sessionID":"s1"}}`,
+	`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","cost":0.5,"tokens":{"input":10,"output":5,"reasoning":0},"time":{"created":1,"completed":2}}}}`,
+	`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s1","role":"assistant","time":{"created":1,"completed":2}}}}`,
+}
+
+func newEventServer(t *testing.T, events []string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/event" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, ev := range events {
+			fmt.Fprintf(w, "data: %s\n\n", ev)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// streamOutput starts SSE against the given events and returns everything the
+// proxy wrote once the server has closed the stream.
+func streamOutput(t *testing.T, events []string, raw bool) []Response {
+	t.Helper()
+	srv := newEventServer(t, events)
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+	var out bytes.Buffer
+	p.codec = &jsonCodec{framer: newLineFramer(nil, &out)}
+
+	if err := p.startSSE(context.Background(), raw); err != nil {
+		t.Fatalf("startSSE: %v", err)
+	}
+	<-p.sse.done
+
+	var responses []Response
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r Response
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid line %q: %v", line, err)
+		}
+		responses = append(responses, r)
+	}
+	return responses
+}
+
+func TestSSEEventsAreNormalized(t *testing.T) {
+	got := streamOutput(t, streamEvents, false)
+
+	want := []struct {
+		typ  string
+		data string
+	}{
+		{EventMessageStarted, `{"session_id":"s1","message_id":"a1","agent":"build","provider_id":"p","model_id":"m"}`},
+		{EventPartDelta, `{"session_id":"s1","message_id":"a1","part_id":"t1","kind":"text","text":"Hel"}`},
+		{EventPartDelta, `{"session_id":"s1","message_id":"a1","part_id":"t1","kind":"text","text":"lo"}`},
+		{EventPartSet, `{"session_id":"s1","message_id":"a1","part_id":"t1","kind":"text","text":"Hello!"}`},
+		{EventToolState, `{"session_id":"s1","message_id":"a1","part_id":"x1","call_id":"c1","tool":"bash","status":"running","input":{"command":"ls"}}`},
+		{EventToolState, `{"session_id":"s1","message_id":"a1","part_id":"x1","call_id":"c1","tool":"bash","status":"completed","output":"a.go"}`},
+		{EventMessageCompleted, `{"session_id":"s1","message_id":"a1","tokens":{"input":10,"output":5,"reasoning":0},"cost":0.5}`},
+	}
+	if len(got) != len(want) {
+		for _, r := range got {
+			b, _ := json.Marshal(r)
+			t.Log(string(b))
+		}
+		t.Fatalf("expected %d events, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		data, _ := json.Marshal(got[i].Data)
+		var wantData any
+		json.Unmarshal([]byte(w.data), &wantData)
+		wantJSON, _ := json.Marshal(wantData)
+		if got[i].Type != w.typ || string(data) != string(wantJSON) {
+			t.Errorf("event %d: got %s %s, want %s %s", i, got[i].Type, data, w.typ, w.data)
+		}
+	}
+}
+
+func TestSSERawPassthrough(t *testing.T) {
+	got := streamOutput(t, streamEvents, true)
+
+	var raw, normalized int
+	for _, r := range got {
+		if r.Type == EventRaw {
+			raw++
+		} else {
+			normalized++
+		}
+	}
+	if raw != len(streamEvents) || normalized != 7 {
+		t.Fatalf("expected %d raw and 7 normalized events, got %d and %d", len(streamEvents), raw, normalized)
+	}
+	if got[0].Type != EventRaw || got[0].Data.(map[string]any)["type"] != "message.updated" {
+		t.Fatalf("raw event should be forwarded verbatim first: %+v", got[0])
+	}
+}
+
+func TestSSEStartReportsConnectErrors(t *testing.T) {
+	srv := newSessionServer(t)
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+
+	got := byID(runLines(t, p, `{"id":1,"type":"sse.start"}`, `{"id":2,"type":"sse.stop"}`))
+	if got["1"].Type != "error" {
+		t.Fatalf("expected sse.start to fail on 404, got %+v", got["1"])
+	}
+	if got["2"].Type != "sse.stopped" {
+		t.Fatalf("expected sse.stop to succeed, got %+v", got["2"])
+	}
+}
@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped; blocking instead would stall every other subscriber.
+const subscriberBuffer = 1024
+
+var errSlowSubscriber = errors.New("event subscription dropped: client is not reading fast enough")
+
+// upstreamConnectTimeout bounds how long subscribers wait for the server to
+// accept the upstream connection.
+const upstreamConnectTimeout = 30 * time.Second
+
+// hub shares one upstream SSE connection among any number of subscribers. The
+// upstream connection is opened for the first subscriber and closed when the
+// last one leaves.
+type hub struct {
+	stream         func() *client.SSEClient
+	connectTimeout time.Duration
+
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+	up   *upstream
+}
+
+// upstream is one connection to the server's event stream. ready is closed
+// once the server has accepted it, or connecting failed with err.
+type upstream struct {
+	cancel context.CancelFunc
+	ready  chan struct{}
+	err    error
+	// pending holds the subscribers waiting for ready; guarded by hub.mu.
+	pending map[*subscription]struct{}
+}
+
+// subscription receives every upstream event until it is closed. When the
+// hub ends it, events is closed and err says why (nil for a clean end).
+type subscription struct {
+	hub    *hub
+	events chan client.SSEEvent
+	err    error
+}
+
+func newHub(stream func() *client.SSEClient) *hub {
+	return &hub{stream: stream, connectTimeout: upstreamConnectTimeout, subs: make(map[*subscription]struct{})}
+}
+
+// subscribe adds a subscriber, connecting upstream first if needed. It
+// returns once the upstream connection has been accepted by the server, or
+// with an error when connecting fails, times out or ctx is done. h.mu is not
+// held meanwhile, so a slow server does not block other subscribers.
+func (h *hub) subscribe(ctx context.Context) (*subscription, error) {
+	s := &subscription{hub: h, events: make(chan client.SSEEvent, subscriberBuffer)}
+	h.mu.Lock()
+	up := h.up
+	if up == nil {
+		up = h.connect()
+		h.up = up
+	}
+	select {
+	case <-up.ready:
+		h.subs[s] = struct{}{}
+		h.mu.Unlock()
+		return s, nil
+	default:
+		up.pending[s] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	select {
+	case <-up.ready:
+	case <-ctx.Done():
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	select {
+	case <-up.ready:
+		if up.err != nil {
+			return nil, up.err
+		}
+		return s, nil
+	default:
+		delete(up.pending, s)
+		h.release(up)
+		return nil, ctx.Err()
+	}
+}
+
+// connect starts opening an upstream connection and returns without waiting
+// for it. h.mu must be held.
+func (h *hub) connect() *upstream {
+	ctx, cancel := context.WithCancel(context.Background())
+	up := &upstream{cancel: cancel, ready: make(chan struct{}), pending: make(map[*subscription]struct{})}
+	connected := make(chan struct{})
+	sc := h.stream()
+	sc.OnConnect = func() { close(connected) }
+
+	events := make(chan client.SSEEvent, 32)
+	errs := make(chan error, 1)
+	go func() {
+		sc.Connect(ctx, events, errs)
+		close(events)
+	}()
+	go h.await(up, connected, events, errs)
+	return up
+}
+
+// await waits until the server accepts up, then subscribes the pending
+// subscribers and pumps its events. On failure it records the error and
+// forgets up, so the next subscriber reconnects.
+func (h *hub) await(up *upstream, connected <-chan struct{}, events <-chan client.SSEEvent, errs <-chan error) {
+	timer := time.NewTimer(h.connectTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-connected:
+	case err = <-errs:
+	case <-timer.C:
+		err = fmt.Errorf("event stream: server did not respond within %s", h.connectTimeout)
+	}
+
+	h.mu.Lock()
+	if err != nil {
+		up.cancel()
+		up.err = err
+		if h.up == up {
+			h.up = nil
+		}
+	} else {
+		for s := range up.pending {
+			h.subs[s] = struct{}{}
+		}
+	}
+	up.pending = nil
+	close(up.ready)
+	h.mu.Unlock()
+	if err == nil {
+		h.pump(up, events, errs)
+	}
+}
+
+// pump fans upstream events out to the subscribers until the connection ends.
+func (h *hub) pump(up *upstream, events <-chan client.SSEEvent, errs <-chan error) {
+	for ev := range events {
+		h.mu.Lock()
+		if h.up == up {
+			for s := range h.subs {
+				select {
+				case s.events <- ev:
+				default:
+					h.drop(s, errSlowSubscriber)
+				}
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	var err error
+	select {
+	case e := <-errs:
+		err = fmt.Errorf("SSE read error: %w", e)
+	default:
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.up != up {
+		return
+	}
+	h.up = nil
+	for s := range h.subs {
+		h.drop(s, err)
+	}
+}
+
+// drop ends s with err. h.mu must be held.
+func (h *hub) drop(s *subscription, err error) {
+	delete(h.subs, s)
+	s.err = err
+	close(s.events)
+}
+
+// close unsubscribes s, closing the upstream connection if it was the last
+// subscriber. It is safe to call after the hub has dropped s.
+func (s *subscription) close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[s]; ok {
+		h.drop(s, nil)
+	}
+	if h.up != nil {
+		h.release(h.up)
+	}
+}
+
+// release closes up if it is still current and nobody is subscribed to it or
+// waiting for it. h.mu must be held.
+func (h *hub) release(up *upstream) {
+	if h.up == up && len(up.pending) == 0 && len(h.subs) == 0 {
+		up.cancel()
+		h.up = nil
+	}
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+// newSilentServer accepts /event but never sends response headers, reporting
+// each request on requests.
+func newSilentServer(t *testing.T) (*httptest.Server, <-chan struct{}) {
+	t.Helper()
+	requests := make(chan struct{}, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func TestHubDoesNotBlockWhileConnecting(t *testing.T) {
+	srv, requests := newSilentServer(t)
+	cli := client.New(client.Config{BaseURL: srv.URL})
+	h := newHub(cli.EventStream)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := h.subscribe(ctx)
+		first <- err
+	}()
+	<-requests
+
+	short, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	start := time.Now()
+	if _, err := h.subscribe(short); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the second subscriber to give up, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("second subscriber blocked behind the first")
+	}
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the first subscriber to be cancelled, got %v", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.up != nil {
+		t.Fatalf("upstream must be closed once nobody waits for it")
+	}
+}
+
+func TestHubConnectTimesOut(t *testing.T) {
+	srv, _ := newSilentServer(t)
+	cli := client.New(client.Config{BaseURL: srv.URL})
+	h := newHub(cli.EventStream)
+	h.connectTimeout = 50 * time.Millisecond
+
+	_, err := h.subscribe(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "did not respond within 50ms") {
+		t.Fatalf("expected connect timeout, got %v", err)
+	}
+}
+
+func TestCancelSSEStart(t *testing.T) {
+	srv, requests := newSilentServer(t)
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+
+	in, w := io.Pipe()
+	var out bytes.Buffer
+	c, err := newCodec(ProtocolJSON, FramingLine, in, &out)
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	go func() {
+		io.WriteString(w, `{"id":1,"type":"sse.start"}`+"\n")
+		<-requests
+		io.WriteString(w, `{"id":2,"type":"cancel","payload":{"id":1}}`+"\n")
+		w.Close()
+	}()
+	p.serve(c)
+
+	var responses []Response
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r Response
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		responses = append(responses, r)
+	}
+	got := byID(responses)
+	if got["1"].Type != "error" || got["1"].Data.(map[string]any)["message"] != errCancelled.Error() {
+		t.Fatalf("expected cancelled sse.start, got %+v", got["1"])
+	}
+	if data := got["2"].Data.(map[string]any); got["2"].Type != "cancelled" || data["cancelled"] != true {
+		t.Fatalf("unexpected cancel reply: %+v", got["2"])
+	}
+}
@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JSON-RPC 2.0 error codes. The -320xx range is reserved for
+// implementation-defined server errors.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+	rpcNoSession      = -32001
+	rpcCancelled      = -32800
+)
+
+const rpcVersion = "2.0"
+
+// rpcCancelMethod is the LSP cancellation notification, accepted as an alias
+// of the cancel command.
+const rpcCancelMethod = "$/cancelRequest"
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResult struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+}
+
+type rpcErrorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   rpcError        `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+var errInvalidRequest = errors.New("invalid request")
+
+// rpcCodec speaks JSON-RPC 2.0: methods mirror the native command types,
+// params mirror their payloads, and results carry the native reply data.
+// Events (ready, sse, ...) are sent as notifications named after their type.
+// Requests without an id are notifications and get no response.
+type rpcCodec struct {
+	framer framer
+}
+
+func (c *rpcCodec) ReadCommand() (Command, error) {
+	msg, err := c.framer.ReadMessage()
+	if err != nil {
+		return Command{}, err
+	}
+	if trimmed := bytes.TrimSpace(msg); len(trimmed) > 0 && trimmed[0] == '[' {
+		return Command{}, &decodeError{fmt.Errorf("%w: batch requests are not supported", errInvalidRequest)}
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return Command{}, &decodeError{fmt.Errorf("parse error: %v", err)}
+	}
+	cmd := Command{ID: req.ID, Type: req.Method, Payload: req.Params}
+	if req.JSONRPC != rpcVersion {
+		return cmd, &decodeError{fmt.Errorf("%w: jsonrpc must be %q", errInvalidRequest, rpcVersion)}
+	}
+	if req.Method == "" {
+		return cmd, &decodeError{fmt.Errorf("%w: missing method", errInvalidRequest)}
+	}
+	return cmd, nil
+}
+
+func (c *rpcCodec) WriteReply(cmd Command, replyType string, data any) error {
+	if len(cmd.ID) == 0 {
+		return nil
+	}
+	return c.write(rpcResult{JSONRPC: rpcVersion, ID: cmd.ID, Result: data})
+}
+
+func (c *rpcCodec) WriteError(cmd Command, err error) error {
+	var de *decodeError
+	isDecode := errors.As(err, &de)
+	if len(cmd.ID) == 0 && !isDecode {
+		return nil
+	}
+	id := cmd.ID
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return c.write(rpcErrorResponse{
+		JSONRPC: rpcVersion,
+		ID:      id,
+		Error:   rpcError{Code: rpcErrorCode(err), Message: err.Error()},
+	})
+}
+
+func (c *rpcCodec) WriteEvent(eventType string, data any) error {
+	return c.write(rpcNotification{JSONRPC: rpcVersion, Method: eventType, Params: data})
+}
+
+func (c *rpcCodec) write(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.framer.WriteMessage(b)
+}
+
+func rpcErrorCode(err error) int {
+	var de *decodeError
+	var pe *payloadError
+	switch {
+	case errors.Is(err, errInvalidRequest):
+		return rpcInvalidRequest
+	case errors.As(err, &de):
+		return rpcParseError
+	case errors.Is(err, errUnknownCommand):
+		return rpcMethodNotFound
+	case errors.As(err, &pe):
+		return rpcInvalidParams
+	case errors.Is(err, errNoSession):
+		return rpcNoSession
+	case errors.Is(err, errCancelled):
+		return rpcCancelled
+	default:
+		return rpcServerError
+	}
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newSessionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/session" && r.Method == http.MethodPost:
+			io.WriteString(w, `{"id":"ses-rpc"}`)
+		case r.URL.Path == "/session":
+			io.WriteString(w, `[{"id":"ses-rpc","title":"rpc"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func decodeRPC(t *testing.T, lines []string) []map[string]any {
+	t.Helper()
+	var msgs []map[string]any
+	for _, line := range lines {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid message %q: %v", line, err)
+		}
+		if m["jsonrpc"] != "2.0" {
+			t.Fatalf("missing jsonrpc version: %v", m)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func TestJSONRPCLineFraming(t *testing.T) {
+	srv := newSessionServer(t)
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"session.create","params":{"title":"x"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"session.select","params":"bad"}`,
+		`{"jsonrpc":"2.0","method":"session.select","params":{"id":"quiet"}}`,
+		`{not json`,
+		`{"jsonrpc":"1.0","id":4,"method":"health"}`,
+	}, "\n") + "\n"
+	var out bytes.Buffer
+	c, err := newCodec(ProtocolJSONRPC, FramingLine, strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	p.serve(c)
+
+	msgs := decodeRPC(t, strings.Split(strings.TrimSpace(out.String()), "\n"))
+	if len(msgs) != 6 {
+		t.Fatalf("expected ready + 5 responses (notification silent), got %d: %s", len(msgs), out.String())
+	}
+	if msgs[0]["method"] != "ready" {
+		t.Fatalf("expected ready notification, got %v", msgs[0])
+	}
+	if result := msgs[1]["result"].(map[string]any); result["id"] != "ses-rpc" {
+		t.Fatalf("unexpected create result: %v", msgs[1])
+	}
+
+	wantCodes := []float64{rpcMethodNotFound, rpcInvalidParams, rpcParseError, rpcInvalidRequest}
+	for i, want := range wantCodes {
+		msg := msgs[i+2]
+		code := msg["error"].(map[string]any)["code"].(float64)
+		if code != want {
+			t.Errorf("response %d: expected code %v, got %v (%v)", i+2, want, code, msg)
+		}
+	}
+	if msgs[4]["id"] != nil {
+		t.Fatalf("parse errors must carry a null id: %v", msgs[4])
+	}
+	if p.config.SessionID != "quiet" {
+		t.Fatalf("notifications must still run, session=%q", p.config.SessionID)
+	}
+}
+
+func TestJSONRPCContentLengthFraming(t *testing.T) {
+	srv := newSessionServer(t)
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+
+	body := "{\"jsonrpc\":\"2.0\",\n\"id\":\"a\",\"method\":\"session.list\"}"
+	input := fmt.Sprintf("Content-Length: %d\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n%s", len(body), body)
+	var out bytes.Buffer
+	c, err := newCodec(ProtocolJSONRPC, FramingContentLength, strings.NewReader(input), &out)
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	p.serve(c)
+
+	r := newContentLengthFramer(&out, io.Discard)
+	var lines []string
+	for {
+		msg, err := r.ReadMessage()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read framed output: %v", err)
+		}
+		lines = append(lines, string(msg))
+	}
+	msgs := decodeRPC(t, lines)
+	if len(msgs) != 2 || msgs[1]["id"] != "a" {
+		t.Fatalf("unexpected framed responses: %v", msgs)
+	}
+	if sessions := msgs[1]["result"].([]any); len(sessions) != 1 {
+		t.Fatalf("unexpected session list: %v", msgs[1])
+	}
+}
+
+func TestNewCodecRejectsUnknownOptions(t *testing.T) {
+	if _, err := newCodec("xml", "", strings.NewReader(""), io.Discard); err == nil {
+		t.Errorf("expected unknown protocol error")
+	}
+	if _, err := newCodec("", "chunked", strings.NewReader(""), io.Discard); err == nil {
+		t.Errorf("expected unknown framing error")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package proxy
+
+import "net"
+
+// listenUnix creates the socket; there is no umask to narrow its initial
+// mode on this platform.
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
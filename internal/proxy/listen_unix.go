@@ -0,0 +1,18 @@
+//go:build unix
+
+package proxy
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix creates the socket with a umask that leaves it accessible to
+// the owner only, so other users cannot connect before Listen tightens its
+// mode. The umask is process-wide; files created concurrently only end up
+// more restrictive.
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0o177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -1,30 +1,50 @@
 package proxy
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"log"
 	"os"
-	"strings"
 	"sync"
+	"time"
+
+	"miniopencode/internal/client"
 )
 
-// Config holds proxy configuration.
+// Config holds proxy configuration. Protocol selects the wire protocol
+// (ProtocolJSON or ProtocolJSONRPC) and Framing how messages are delimited
+// (FramingLine or FramingContentLength); both default to the former.
 type Config struct {
 	Host            string
 	Port            string
 	BaseURLOverride string
 	SessionID       string
+	Protocol        string
+	Framing         string
+	// Timeout bounds each HTTP request to the server; zero uses the client
+	// default.
+	Timeout time.Duration
+	// Server carries the connection settings (credentials, headers, TLS and
+	// an optional base URL) applied to every request and the event stream.
+	Server client.Config
 }
 
-// BaseURL returns an explicit base URL if provided, otherwise builds from host/port.
+// BaseURL returns an explicit base URL if provided, then Server.BaseURL,
+// then client.SocketBaseURL for a Unix socket, otherwise builds from
+// host/port.
 func (c Config) BaseURL() string {
 	if c.BaseURLOverride != "" {
 		return c.BaseURLOverride
 	}
+	if c.Server.BaseURL != "" {
+		return c.Server.BaseURL
+	}
+	if c.Server.Socket != "" {
+		return client.SocketBaseURL
+	}
 	host := c.Host
 	if host == "" {
 		host = "127.0.0.1"
@@ -36,42 +56,120 @@ func (c Config) BaseURL() string {
 	return fmt.Sprintf("http://%s:%s", host, port)
 }
 
-// Command types for stdin commands.
+// Command types for stdin commands. ID is optional and opaque (string or
+// number); when present it is echoed on every response to the command.
 type Command struct {
+	ID      json.RawMessage `json:"id,omitempty"`
 	Type    string          `json:"type"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
+// Response is a message written to stdout, either in reply to a command (ID set
+// when the command had one) or as an unsolicited event such as "sse".
+type Response struct {
+	Type string          `json:"type"`
+	ID   json.RawMessage `json:"id,omitempty"`
+	Data any             `json:"data"`
+}
+
 type PromptPayload struct {
 	Text       string `json:"text"`
 	ProviderID string `json:"provider_id,omitempty"`
 	ModelID    string `json:"model_id,omitempty"`
+	Agent      string `json:"agent,omitempty"`
+	System     string `json:"system,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+	NoReply    bool   `json:"no_reply,omitempty"`
 }
 
+// PromptWaitPayload is a prompt that waits for the answer. Timeout is a
+// duration such as "90s"; it defaults to defaultPromptWaitTimeout.
+type PromptWaitPayload struct {
+	PromptPayload
+	Timeout string `json:"timeout,omitempty"`
+}
+
+const defaultPromptWaitTimeout = 5 * time.Minute
+
 type SessionPayload struct {
 	Title string `json:"title,omitempty"`
 	ID    string `json:"id,omitempty"`
 }
 
+// CancelPayload names the in-flight command to cancel by its id.
+type CancelPayload struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// SSEPayload configures sse.start. Raw additionally forwards every server
+// event verbatim as an "sse" event.
+type SSEPayload struct {
+	Raw bool `json:"raw,omitempty"`
+}
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errNoSession      = errors.New("no session selected")
+	errCancelled      = errors.New("command cancelled")
+)
+
+// payloadError is a command payload that does not match the command.
+type payloadError struct {
+	err error
+}
+
+func (e *payloadError) Error() string { return e.err.Error() }
+func (e *payloadError) Unwrap() error { return e.err }
+
+// commandQueue is how many commands may wait behind a barrier before input
+// is no longer read.
+const commandQueue = 64
+
+// pendingCommand is an in-flight command that can be cancelled by id.
+type pendingCommand struct {
+	cancel context.CancelFunc
+}
+
 // Proxy handles communication between stdin/stdout and opencode server.
 type Proxy struct {
-	config    Config
-	baseURL   string
-	sseClient *http.Client
-	mu        sync.Mutex
-	sseResp   *http.Response
+	config   Config
+	baseURL  string
+	client   *client.Client
+	hub      *hub
+	mu       sync.Mutex
+	cancels  map[string]*pendingCommand
+	sse      *sseStream
+	codec    codec
+	inflight sync.WaitGroup
 }
 
 // NewProxy constructs a Proxy with a computed base URL.
 func NewProxy(config Config) *Proxy {
-	baseURL := config.BaseURL()
-	config.BaseURLOverride = baseURL
-	return &Proxy{
-		config:    config,
-		baseURL:   baseURL,
-		sseClient: &http.Client{},
+	cli := newClient(&config)
+	return newProxy(config, cli, newHub(cli.EventStream))
+}
+
+// newClient builds the client for config's server and pins the computed base
+// URL in config.
+func newClient(config *Config) *client.Client {
+	cc := config.Server
+	cc.BaseURL = config.BaseURL()
+	if config.Timeout != 0 {
+		cc.Timeout = config.Timeout
 	}
+	config.BaseURLOverride = cc.BaseURL
+	return client.New(cc)
+}
 
+// newProxy builds the per-connection state around a shared client and hub.
+func newProxy(config Config, cli *client.Client, h *hub) *Proxy {
+	return &Proxy{
+		config:  config,
+		baseURL: cli.BaseURL(),
+		client:  cli,
+		hub:     h,
+		cancels: make(map[string]*pendingCommand),
+	}
 }
 
 // BaseURL returns the computed base URL.
@@ -79,274 +177,400 @@ func (p *Proxy) BaseURL() string {
 	return p.baseURL
 }
 
+// Client returns the opencode client the proxy delegates to.
+func (p *Proxy) Client() *client.Client {
+	return p.client
+}
+
 // CheckHealth checks if server is running.
 func (p *Proxy) CheckHealth() bool {
-	resp, err := http.Get(p.baseURL + "/global/health")
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-	return resp.StatusCode == http.StatusOK
+	return p.client.Health(context.Background()) == nil
 }
 
-// createSession creates a new session.
-func (p *Proxy) createSession(title string) (string, error) {
-	body := map[string]string{"title": title}
-	b, _ := json.Marshal(body)
-
-	resp, err := http.Post(p.baseURL+"/session", "application/json", bytes.NewReader(b))
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
-
-	if id, ok := result["id"].(string); ok {
-		return id, nil
+// promptInput converts a prompt payload to the client request body.
+func (payload PromptPayload) promptInput() client.PromptInput {
+	input := client.PromptInput{
+		Parts:   []client.InputPart{{Type: "text", Text: payload.Text}},
+		Agent:   payload.Agent,
+		System:  payload.System,
+		Variant: payload.Variant,
+		NoReply: payload.NoReply,
 	}
-	if id, ok := result["ID"].(string); ok {
-		return id, nil
+	if payload.ProviderID != "" && payload.ModelID != "" {
+		input.Model = &client.ModelRef{ProviderID: payload.ProviderID, ModelID: payload.ModelID}
 	}
-	return "", fmt.Errorf("no session ID in response")
+	return input
 }
 
-// listSessions lists all sessions.
-func (p *Proxy) listSessions() ([]map[string]interface{}, error) {
-	resp, err := http.Get(p.baseURL + "/session")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var sessions []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
-		return nil, err
-	}
-	return sessions, nil
+// sseStream is a running SSE subscription.
+type sseStream struct {
+	sub  *subscription
+	done chan struct{}
 }
 
-// sendPrompt sends a message to the session.
-func (p *Proxy) sendPrompt(sessionID string, payload PromptPayload) error {
-	body := map[string]interface{}{
-		"parts": []map[string]string{
-			{"type": "text", "text": payload.Text},
-		},
-	}
-
-	if payload.ProviderID != "" && payload.ModelID != "" {
-		body["model"] = map[string]string{
-			"providerID": payload.ProviderID,
-			"modelID":    payload.ModelID,
-		}
-	}
-
-	b, _ := json.Marshal(body)
+// startSSE subscribes to the server's event stream, replacing any previous
+// subscription, and returns once the server has accepted it or ctx is done.
+// Events are written as normalized events, and also verbatim as "sse" when
+// raw is set.
+func (p *Proxy) startSSE(ctx context.Context, raw bool) error {
+	p.stopSSE()
 
-	url := fmt.Sprintf("%s/session/%s/prompt_async", p.baseURL, sessionID)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	sub, err := p.hub.subscribe(ctx)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("prompt failed: %s", string(body))
-	}
+	stream := &sseStream{sub: sub, done: make(chan struct{})}
+	p.mu.Lock()
+	p.sse = stream
+	p.mu.Unlock()
 
+	go func() {
+		defer close(stream.done)
+		n := newNormalizer()
+		for ev := range sub.events {
+			p.forwardEvent(n, ev, raw)
+		}
+		if sub.err != nil {
+			p.outputError(sub.err)
+		}
+	}()
 	return nil
 }
 
-// startSSE connects to SSE endpoint and streams events to stdout.
-func (p *Proxy) startSSE() error {
-	req, err := http.NewRequest(http.MethodGet, p.baseURL+"/event", nil)
-	if err != nil {
-		return err
+// stopSSE ends the current subscription, if any, and waits until none of its
+// events can still be written.
+func (p *Proxy) stopSSE() {
+	p.mu.Lock()
+	stream := p.sse
+	p.sse = nil
+	p.mu.Unlock()
+	if stream == nil {
+		return
 	}
-	req.Header.Set("Accept", "text/event-stream")
-	req.Header.Set("Cache-Control", "no-cache")
+	stream.sub.close()
+	<-stream.done
+}
 
-	resp, err := p.sseClient.Do(req)
+// forwardEvent writes the normalized events for ev, preceded by ev itself
+// when raw passthrough is enabled.
+func (p *Proxy) forwardEvent(n *normalizer, ev client.SSEEvent, raw bool) {
+	if len(ev.Data) == 0 {
+		return
+	}
+	if raw && json.Valid(ev.Data) {
+		p.output(EventRaw, json.RawMessage(ev.Data))
+	}
+	parsed, err := client.ParseEvent(ev)
 	if err != nil {
-		return err
+		log.Printf("proxy: parse event error: %v", err)
+		return
+	}
+	for _, e := range n.normalize(parsed) {
+		p.output(e.Type, e.Data)
 	}
-
-	p.mu.Lock()
-	p.sseResp = resp
-	p.mu.Unlock()
-
-	go p.readSSE(resp)
-	return nil
 }
 
-// output sends JSON to stdout.
+// output sends an unsolicited event to stdout.
 func (p *Proxy) output(eventType string, data interface{}) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-
-	out := map[string]interface{}{
-		"type": eventType,
-		"data": data,
-	}
-	b, _ := json.Marshal(out)
-	fmt.Println(string(b))
+	p.codec.WriteEvent(eventType, data)
 }
 
-// outputError sends error to stdout.
+// outputError sends an error that is not tied to a command to stdout.
 func (p *Proxy) outputError(err error) {
 	p.output("error", map[string]string{"message": err.Error()})
 }
 
-// outputRaw sends raw SSE event to stdout.
-func (p *Proxy) outputRaw(eventType string, raw json.RawMessage) {
+// reply sends the response to cmd, correlated by its id.
+func (p *Proxy) reply(cmd Command, replyType string, data interface{}) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.codec.WriteReply(cmd, replyType, data)
+}
 
-	out := map[string]interface{}{
-		"type": eventType,
-		"data": raw,
-	}
-	b, _ := json.Marshal(out)
-	fmt.Println(string(b))
+// replyError sends an error caused by cmd, echoing its id and type.
+func (p *Proxy) replyError(cmd Command, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codec.WriteError(cmd, err)
 }
 
-// readSSE reads SSE events and outputs to stdout.
-func (p *Proxy) readSSE(resp *http.Response) {
-	defer resp.Body.Close()
+// concurrent reports whether a command can run alongside others. Commands
+// that change the selected session or the SSE stream are barriers: they wait
+// for in-flight commands and run alone, so everything sent after them sees
+// their effect.
+func concurrent(cmdType string) bool {
+	switch cmdType {
+	case "health", "session.list", "prompt", "prompt.wait":
+		return true
+	default:
+		return false
+	}
+}
 
-	scanner := bufio.NewScanner(resp.Body)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+// isCancel reports whether cmd cancels another command. Cancels are handled
+// as soon as they are read, ahead of queued commands and during barriers.
+func isCancel(cmd Command) bool {
+	return cmd.Type == "cancel" || cmd.Type == rpcCancelMethod
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// queuedCommand is a command read from the input, registered for
+// cancellation and waiting to be dispatched. A command that could not be
+// decoded carries err and is only answered with it, in order.
+type queuedCommand struct {
+	cmd     Command
+	err     error
+	ctx     context.Context
+	release func()
+}
 
-		if strings.HasPrefix(line, "data: ") {
-			data := strings.TrimPrefix(line, "data: ")
-			if data == "" {
-				continue
-			}
+// dispatch runs q inline when it is a barrier, or in the background.
+func (p *Proxy) dispatch(q queuedCommand) {
+	if q.err != nil {
+		p.replyError(q.cmd, q.err)
+		return
+	}
+	if !concurrent(q.cmd.Type) {
+		p.inflight.Wait()
+		p.run(q.ctx, q.cmd)
+		q.release()
+		return
+	}
+	p.inflight.Add(1)
+	go func() {
+		defer p.inflight.Done()
+		defer q.release()
+		p.run(q.ctx, q.cmd)
+	}()
+}
 
-			var event map[string]interface{}
-			if err := json.Unmarshal([]byte(data), &event); err != nil {
-				continue
-			}
+// run executes cmd and writes its reply. A command cancelled while queued is
+// not executed.
+func (p *Proxy) run(ctx context.Context, cmd Command) {
+	if ctx.Err() != nil {
+		p.replyError(cmd, errCancelled)
+		return
+	}
+	replyType, data, err := p.handleCommand(ctx, cmd)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			err = errCancelled
+		}
+		p.replyError(cmd, err)
+		return
+	}
+	p.reply(cmd, replyType, data)
+}
 
-			p.output("sse", event)
+// commandContext returns the context for cmd. Commands with an id can be
+// cancelled by it until release is called.
+func (p *Proxy) commandContext(cmd Command) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if len(cmd.ID) == 0 {
+		return ctx, cancel
+	}
+	key := string(cmd.ID)
+	entry := &pendingCommand{cancel: cancel}
+	p.mu.Lock()
+	p.cancels[key] = entry
+	p.mu.Unlock()
+	return ctx, func() {
+		p.mu.Lock()
+		if p.cancels[key] == entry {
+			delete(p.cancels, key)
 		}
+		p.mu.Unlock()
+		cancel()
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		p.outputError(fmt.Errorf("SSE read error: %v", err))
+// cancelCommand cancels the in-flight command with the given id, reporting
+// whether one was found.
+func (p *Proxy) cancelCommand(id json.RawMessage) bool {
+	p.mu.Lock()
+	entry, ok := p.cancels[string(id)]
+	p.mu.Unlock()
+	if ok {
+		entry.cancel()
 	}
+	return ok
 }
 
-// handleCommand processes a command from stdin.
-func (p *Proxy) handleCommand(cmd Command) {
+// handleCommand executes a command and returns the reply type and data. It is
+// shared by every protocol; only the encoding of the result differs.
+func (p *Proxy) handleCommand(ctx context.Context, cmd Command) (string, any, error) {
 	switch cmd.Type {
 	case "health":
-		healthy := p.CheckHealth()
-		p.output("health", map[string]bool{"healthy": healthy})
+		healthy := p.client.Health(ctx) == nil
+		return "health", map[string]bool{"healthy": healthy}, nil
 
 	case "session.create":
 		var payload SessionPayload
-		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
-			p.outputError(err)
-			return
+		if err := decodePayload(cmd, &payload); err != nil {
+			return "", nil, err
 		}
-		id, err := p.createSession(payload.Title)
+		id, err := p.client.CreateSession(ctx, payload.Title)
 		if err != nil {
-			p.outputError(err)
-			return
+			return "", nil, err
 		}
 		p.config.SessionID = id
-		p.output("session.created", map[string]string{"id": id})
+		return "session.created", map[string]string{"id": id}, nil
 
 	case "session.list":
-		sessions, err := p.listSessions()
+		sessions, err := p.client.ListSessionsRaw(ctx)
 		if err != nil {
-			p.outputError(err)
-			return
+			return "", nil, err
 		}
-		p.output("session.list", sessions)
+		return "session.list", sessions, nil
 
 	case "session.select":
 		var payload SessionPayload
-		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
-			p.outputError(err)
-			return
+		if err := decodePayload(cmd, &payload); err != nil {
+			return "", nil, err
 		}
 		p.config.SessionID = payload.ID
-		p.output("session.selected", map[string]string{"id": payload.ID})
+		return "session.selected", map[string]string{"id": payload.ID}, nil
 
 	case "prompt":
-		if p.config.SessionID == "" {
-			p.outputError(fmt.Errorf("no session selected"))
-			return
+		sessionID := p.config.SessionID
+		if sessionID == "" {
+			return "", nil, errNoSession
 		}
 		var payload PromptPayload
-		if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
-			p.outputError(err)
-			return
+		if err := decodePayload(cmd, &payload); err != nil {
+			return "", nil, err
 		}
-		if err := p.sendPrompt(p.config.SessionID, payload); err != nil {
-			p.outputError(err)
-			return
+		if err := p.client.SendPromptAsync(ctx, sessionID, payload.promptInput()); err != nil {
+			return "", nil, err
 		}
-		p.output("prompt.sent", map[string]string{"session_id": p.config.SessionID})
+		return "prompt.sent", map[string]string{"session_id": sessionID}, nil
+
+	case "prompt.wait":
+		sessionID := p.config.SessionID
+		if sessionID == "" {
+			return "", nil, errNoSession
+		}
+		var payload PromptWaitPayload
+		if err := decodePayload(cmd, &payload); err != nil {
+			return "", nil, err
+		}
+		timeout := defaultPromptWaitTimeout
+		if payload.Timeout != "" {
+			d, err := time.ParseDuration(payload.Timeout)
+			if err != nil || d <= 0 {
+				return "", nil, &payloadError{fmt.Errorf("invalid timeout %q", payload.Timeout)}
+			}
+			timeout = d
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := p.client.PromptWait(ctx, sessionID, payload.promptInput())
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", nil, fmt.Errorf("no answer within %s", timeout)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return "prompt.result", result, nil
 
 	case "sse.start":
-		if err := p.startSSE(); err != nil {
-			p.outputError(err)
-			return
+		var payload SSEPayload
+		if len(cmd.Payload) > 0 {
+			if err := decodePayload(cmd, &payload); err != nil {
+				return "", nil, err
+			}
 		}
-		p.output("sse.started", nil)
+		if err := p.startSSE(ctx, payload.Raw); err != nil {
+			return "", nil, err
+		}
+		return "sse.started", nil, nil
 
 	case "sse.stop":
-		p.mu.Lock()
-		if p.sseResp != nil {
-			p.sseResp.Body.Close()
-			p.sseResp = nil
+		p.stopSSE()
+		return "sse.stopped", nil, nil
+
+	case "cancel", rpcCancelMethod:
+		var payload CancelPayload
+		if err := decodePayload(cmd, &payload); err != nil {
+			return "", nil, err
 		}
-		p.mu.Unlock()
-		p.output("sse.stopped", nil)
+		if len(payload.ID) == 0 {
+			return "", nil, &payloadError{errors.New("missing id")}
+		}
+		found := p.cancelCommand(payload.ID)
+		return "cancelled", map[string]any{"id": payload.ID, "cancelled": found}, nil
 
 	default:
-		p.outputError(fmt.Errorf("unknown command: %s", cmd.Type))
+		return "", nil, fmt.Errorf("%w: %s", errUnknownCommand, cmd.Type)
 	}
 }
 
-// RunHeadless starts the proxy, reading from stdin and writing to stdout.
-func (p *Proxy) RunHeadless() {
-	scanner := bufio.NewScanner(os.Stdin)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
+// decodePayload unmarshals the command payload into v.
+func decodePayload(cmd Command, v any) error {
+	if err := json.Unmarshal(cmd.Payload, v); err != nil {
+		return &payloadError{err}
+	}
+	return nil
+}
+
+// RunHeadless starts the proxy, reading from stdin and writing to stdout
+// using the configured protocol and framing.
+func (p *Proxy) RunHeadless() error {
+	c, err := newCodec(p.config.Protocol, p.config.Framing, os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	p.serve(c)
+	return nil
+}
+
+// serve reads commands from c until end of input, then waits for in-flight
+// commands to finish and ends the event subscription. Every command is
+// registered for cancellation as it is read and dispatched in order from a
+// queue, so a cancel read right after a command always finds it, even while
+// a barrier holds up the queue.
+func (p *Proxy) serve(c codec) {
+	p.codec = c
+	defer p.stopSSE()
+
+	queue := make(chan queuedCommand, commandQueue)
+	dispatched := make(chan struct{})
+	go func() {
+		defer close(dispatched)
+		for q := range queue {
+			p.dispatch(q)
+		}
+		p.inflight.Wait()
+	}()
+	defer func() {
+		close(queue)
+		<-dispatched
+	}()
 
 	p.output("ready", map[string]string{
 		"host": p.config.Host,
 		"port": p.config.Port,
 	})
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
+	for {
+		cmd, err := c.ReadCommand()
+		if err != nil {
+			var de *decodeError
+			if errors.As(err, &de) {
+				queue <- queuedCommand{cmd: cmd, err: err}
+				continue
+			}
+			if !errors.Is(err, io.EOF) {
+				p.outputError(err)
+			}
+			return
 		}
-
-		var cmd Command
-		if err := json.Unmarshal([]byte(line), &cmd); err != nil {
-			p.outputError(fmt.Errorf("invalid JSON: %v", err))
+		if isCancel(cmd) {
+			p.run(context.Background(), cmd)
 			continue
 		}
-
-		p.handleCommand(cmd)
-	}
-
-	if err := scanner.Err(); err != nil {
-		p.outputError(err)
+		ctx, release := p.commandContext(cmd)
+		queue <- queuedCommand{cmd: cmd, ctx: ctx, release: release}
 	}
 }
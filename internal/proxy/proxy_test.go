@@ -1,9 +1,16 @@
 package proxy
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+
+	"miniopencode/internal/client"
 )
 
 func TestNewProxyBuildsBaseURL(t *testing.T) {
@@ -44,3 +51,210 @@ func TestCheckHealth(t *testing.T) {
 		}
 	})
 }
+
+func runLines(t *testing.T, p *Proxy, lines ...string) []Response {
+	t.Helper()
+	var out bytes.Buffer
+	c, err := newCodec(ProtocolJSON, FramingLine, strings.NewReader(strings.Join(lines, "\n")+"\n"), &out)
+	if err != nil {
+		t.Fatalf("codec: %v", err)
+	}
+	p.serve(c)
+
+	var responses []Response
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var r Response
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		responses = append(responses, r)
+	}
+	return responses
+}
+
+func byID(responses []Response) map[string]Response {
+	m := make(map[string]Response)
+	for _, r := range responses {
+		if len(r.ID) > 0 {
+			m[string(r.ID)] = r
+		}
+	}
+	return m
+}
+
+func TestCommandIDsAreEchoed(t *testing.T) {
+	var prompted []string
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/global/health":
+			w.WriteHeader(http.StatusOK)
+		case strings.HasSuffix(r.URL.Path, "/prompt_async"):
+			mu.Lock()
+			prompted = append(prompted, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+	responses := runLines(t, p,
+		`{"id":1,"type":"health"}`,
+		`{"id":"sel","type":"session.select","payload":{"id":"ses-a"}}`,
+		`{"id":2,"type":"prompt","payload":{"text":"hi"}}`,
+		`{"id":3,"type":"bogus"}`,
+		`{"type":"health"}`,
+	)
+
+	got := byID(responses)
+	if got["1"].Type != "health" {
+		t.Fatalf("expected health reply for id 1, got %+v", got["1"])
+	}
+	if got[`"sel"`].Type != "session.selected" {
+		t.Fatalf("expected string id echoed, got %+v", got[`"sel"`])
+	}
+	if got["3"].Type != "error" {
+		t.Fatalf("expected error for id 3, got %+v", got["3"])
+	}
+	if data := got["3"].Data.(map[string]any); data["command"] != "bogus" {
+		t.Fatalf("error should name the command: %+v", data)
+	}
+	if len(prompted) != 1 || prompted[0] != "/session/ses-a/prompt_async" {
+		t.Fatalf("prompt must see the session selected before it: %v", prompted)
+	}
+	if got["2"].Type != "prompt.sent" {
+		t.Fatalf("unexpected prompt reply: %+v", got["2"])
+	}
+	if len(responses) != 6 {
+		t.Fatalf("expected ready + 5 replies, got %d", len(responses))
+	}
+}
+
+func TestPromptForwardsOptionsThroughClient(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	p := NewProxy(Config{BaseURLOverride: srv.URL, SessionID: "ses-a"})
+	got := byID(runLines(t, p,
+		`{"id":1,"type":"prompt","payload":{"text":"hi","agent":"plan","system":"be brief","variant":"high","provider_id":"p","model_id":"m"}}`,
+	))
+	if got["1"].Type != "prompt.sent" {
+		t.Fatalf("unexpected reply: %+v", got["1"])
+	}
+	if body["agent"] != "plan" || body["system"] != "be brief" || body["variant"] != "high" {
+		t.Fatalf("prompt options not forwarded: %v", body)
+	}
+	if model := body["model"].(map[string]any); model["providerID"] != "p" || model["modelID"] != "m" {
+		t.Fatalf("model not forwarded: %v", body)
+	}
+}
+
+func TestCancelInFlightCommand(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	p := NewProxy(Config{BaseURLOverride: srv.URL, SessionID: "ses-a"})
+	got := byID(runLines(t, p,
+		`{"id":"slow","type":"prompt","payload":{"text":"hi"}}`,
+		`{"id":2,"type":"cancel","payload":{"id":"slow"}}`,
+		`{"id":3,"type":"cancel","payload":{"id":"missing"}}`,
+	))
+	if got[`"slow"`].Type != "error" || got[`"slow"`].Data.(map[string]any)["message"] != errCancelled.Error() {
+		t.Fatalf("expected cancelled prompt, got %+v", got[`"slow"`])
+	}
+	if data := got["2"].Data.(map[string]any); got["2"].Type != "cancelled" || data["cancelled"] != true {
+		t.Fatalf("unexpected cancel reply: %+v", got["2"])
+	}
+	if data := got["3"].Data.(map[string]any); data["cancelled"] != false {
+		t.Fatalf("cancelling an unknown id must report false: %+v", got["3"])
+	}
+}
+
+func TestCreateSessionReportsServerErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, "boom")
+	}))
+	defer srv.Close()
+
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+	got := byID(runLines(t, p, `{"id":1,"type":"session.create","payload":{"title":"x"}}`))
+	if got["1"].Type != "error" {
+		t.Fatalf("expected error reply, got %+v", got["1"])
+	}
+	if p.config.SessionID != "" {
+		t.Fatalf("failed create must not select a session")
+	}
+}
+
+func TestSessionListPassesServerFieldsThrough(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"id":"ses-a","title":"a","directory":"/src/app","share":{"url":"https://x"}}]`)
+	}))
+	defer srv.Close()
+
+	p := NewProxy(Config{BaseURLOverride: srv.URL})
+	got := byID(runLines(t, p, `{"id":1,"type":"session.list"}`))
+	sessions, ok := got["1"].Data.([]any)
+	if got["1"].Type != "session.list" || !ok || len(sessions) != 1 {
+		t.Fatalf("unexpected reply: %+v", got["1"])
+	}
+	s := sessions[0].(map[string]any)
+	if s["directory"] != "/src/app" || s["share"].(map[string]any)["url"] != "https://x" {
+		t.Fatalf("server fields must be passed through: %v", s)
+	}
+}
+
+func TestPromptWaitRepliesWithAnswer(t *testing.T) {
+	// The server gives the user message the messageID of the posted prompt.
+	posted := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/event":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			userID := <-posted
+			for _, ev := range []string{
+				`{"type":"message.updated","properties":{"info":{"id":"u1","sessionID":"ses-a","role":"user"}}}`,
+				`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"ses-a","role":"assistant","parentID":"u1"}}}`,
+				`{"type":"message.part.updated","properties":{"part":{"id":"p1","sessionID":"ses-a","messageID":"a1","type":"text","text":"42"}}}`,
+				`{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"ses-a","role":"assistant","parentID":"u1","finish":"stop","tokens":{"input":3,"output":1,"reasoning":0},"time":{"created":1,"completed":2}}}}`,
+			} {
+				io.WriteString(w, "data: "+strings.ReplaceAll(ev, `"u1"`, `"`+userID+`"`)+"\n\n")
+			}
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/prompt_async"):
+			var input client.PromptInput
+			json.NewDecoder(r.Body).Decode(&input)
+			posted <- input.MessageID
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewProxy(Config{BaseURLOverride: srv.URL, SessionID: "ses-a"})
+	got := byID(runLines(t, p,
+		`{"id":1,"type":"prompt.wait","payload":{"text":"answer?","timeout":"5s"}}`,
+		`{"id":2,"type":"prompt.wait","payload":{"text":"x","timeout":"soon"}}`,
+	))
+	if got["1"].Type != "prompt.result" {
+		t.Fatalf("unexpected reply: %+v", got["1"])
+	}
+	data := got["1"].Data.(map[string]any)
+	if data["text"] != "42" || data["message_id"] != "a1" || data["tokens"].(map[string]any)["input"] != float64(3) {
+		t.Fatalf("unexpected result: %v", data)
+	}
+	if got["2"].Type != "error" {
+		t.Fatalf("invalid timeout must be rejected: %+v", got["2"])
+	}
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"miniopencode/internal/client"
+)
+
+// Server serves the headless protocol to many concurrent connections. Each
+// connection has its own selected session, in-flight commands and event
+// subscription; all of them share one HTTP client and one upstream SSE
+// connection to the opencode server.
+type Server struct {
+	config Config
+	client *client.Client
+	hub    *hub
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewServer builds a Server. config.SessionID is the initial session of every
+// connection.
+func NewServer(config Config) (*Server, error) {
+	if err := checkCodec(config.Protocol, config.Framing); err != nil {
+		return nil, err
+	}
+	cli := newClient(&config)
+	return &Server{
+		config: config,
+		client: cli,
+		hub:    newHub(cli.EventStream),
+		conns:  make(map[net.Conn]struct{}),
+	}, nil
+}
+
+// Serve accepts connections on l until it is closed, then closes the open
+// connections and waits for their handlers to finish.
+func (s *Server) Serve(l net.Listener) error {
+	defer s.wg.Wait()
+	defer s.closeConns()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	log.Printf("proxy: connection from %s", conn.RemoteAddr())
+	c, err := newCodec(s.config.Protocol, s.config.Framing, conn, conn)
+	if err != nil {
+		return
+	}
+	newProxy(s.config, s.client, s.hub).serve(c)
+	log.Printf("proxy: connection from %s closed", conn.RemoteAddr())
+}
+
+func (s *Server) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Listen opens a listener for an address of the form unix:///path/to.sock or
+// tcp://host:port. A stale Unix socket left by a crashed server is removed;
+// one that still accepts connections is an error, as is any other file at
+// the path. Unix sockets are created accessible to the owner only.
+func Listen(addr string) (net.Listener, error) {
+	network, address, ok := strings.Cut(addr, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid listen address %q (want unix:///path or tcp://host:port)", addr)
+	}
+	switch network {
+	case "tcp":
+		return net.Listen("tcp", address)
+	case "unix":
+		if address == "" {
+			return nil, fmt.Errorf("invalid listen address %q: missing socket path", addr)
+		}
+		if info, err := os.Lstat(address); err == nil {
+			if info.Mode()&os.ModeSocket == 0 {
+				return nil, fmt.Errorf("%s exists and is not a socket", address)
+			}
+			if conn, err := net.Dial("unix", address); err == nil {
+				conn.Close()
+				return nil, fmt.Errorf("socket %s is already in use", address)
+			}
+			if err := os.Remove(address); err != nil {
+				return nil, fmt.Errorf("remove stale socket: %w", err)
+			}
+		}
+		l, err := listenUnix(address)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(address, 0o600); err != nil {
+			l.Close()
+			return nil, err
+		}
+		return l, nil
+	default:
+		return nil, fmt.Errorf("unsupported listen network %q (want unix or tcp)", network)
+	}
+}
@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConn struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Scanner
+}
+
+func dialTest(t *testing.T, network, addr string) *testConn {
+	t.Helper()
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	tc := &testConn{t: t, conn: conn, r: bufio.NewScanner(conn)}
+	tc.expect("ready")
+	return tc
+}
+
+func (c *testConn) send(line string) {
+	c.t.Helper()
+	if _, err := fmt.Fprintln(c.conn, line); err != nil {
+		c.t.Fatalf("send: %v", err)
+	}
+}
+
+// expect reads messages until one of the given type arrives.
+func (c *testConn) expect(typ string) Response {
+	c.t.Helper()
+	for c.r.Scan() {
+		var r Response
+		if err := json.Unmarshal(c.r.Bytes(), &r); err != nil {
+			c.t.Fatalf("invalid message %q: %v", c.r.Text(), err)
+		}
+		if r.Type == typ {
+			return r
+		}
+	}
+	c.t.Fatalf("connection ended waiting for %s: %v", typ, c.r.Err())
+	return Response{}
+}
+
+func TestServerSharesUpstreamAcrossConnections(t *testing.T) {
+	var streams atomic.Int32
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var prompted []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/event":
+			streams.Add(1)
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", `{"type":"message.updated","properties":{"info":{"id":"a1","sessionID":"s","role":"assistant"}}}`)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case strings.HasSuffix(r.URL.Path, "/prompt_async"):
+			mu.Lock()
+			prompted = append(prompted, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer upstream.Close()
+
+	dir, err := os.MkdirTemp("", "mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "mini.sock")
+
+	l, err := Listen("unix://" + sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if info, err := os.Stat(sock); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket should be owner-only: %v %v", info.Mode(), err)
+	}
+	srv, err := NewServer(Config{BaseURLOverride: upstream.URL})
+	if err != nil {
+		t.Fatalf("server: %v", err)
+	}
+	served := make(chan error)
+	go func() { served <- srv.Serve(l) }()
+
+	a := dialTest(t, "unix", sock)
+	b := dialTest(t, "unix", sock)
+	for i, c := range []*testConn{a, b} {
+		c.send(fmt.Sprintf(`{"type":"session.select","payload":{"id":"ses-%d"}}`, i))
+		c.expect("session.selected")
+		c.send(`{"type":"sse.start"}`)
+		c.expect("sse.started")
+	}
+	close(release)
+	for _, c := range []*testConn{a, b} {
+		if ev := c.expect(EventMessageStarted); ev.Data.(map[string]any)["message_id"] != "a1" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+		c.send(`{"type":"prompt","payload":{"text":"hi"}}`)
+		c.expect("prompt.sent")
+	}
+	if n := streams.Load(); n != 1 {
+		t.Fatalf("expected one shared upstream SSE connection, got %d", n)
+	}
+	mu.Lock()
+	sort.Strings(prompted)
+	mu.Unlock()
+	if len(prompted) != 2 || prompted[0] != "/session/ses-0/prompt_async" || prompted[1] != "/session/ses-1/prompt_async" {
+		t.Fatalf("each connection should prompt its own session: %v", prompted)
+	}
+
+	l.Close()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("serve: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after the listener closed")
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatalf("socket file should be removed on close: %v", err)
+	}
+}
+
+func TestListenRejectsBadAddresses(t *testing.T) {
+	for _, addr := range []string{"/tmp/x.sock", "udp://127.0.0.1:1", "unix://"} {
+		if l, err := Listen(addr); err == nil {
+			l.Close()
+			t.Errorf("Listen(%q) should fail", addr)
+		}
+	}
+}
+
+func TestListenReplacesStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mini")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "s.sock")
+
+	// A socket left behind by a server that did not shut down cleanly.
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	l, err := Listen("unix://" + sock)
+	if err != nil {
+		t.Fatalf("stale socket should be replaced: %v", err)
+	}
+	defer l.Close()
+	if _, err := Listen("unix://" + sock); err == nil {
+		t.Fatalf("a live socket must not be replaced")
+	}
+}
+
+func TestListenKeepsFilesThatAreNotSockets(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if l, err := Listen("unix://" + path); err == nil {
+		l.Close()
+		t.Fatalf("Listen must refuse a path holding a regular file")
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "keep me" {
+		t.Fatalf("regular file must be left untouched: %q %v", data, err)
+	}
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const lockPoll = 50 * time.Millisecond
+
+// DefaultLockDir returns the per-user directory for session locks:
+// $XDG_RUNTIME_DIR/miniopencode, or a user-specific directory under the
+// temp dir.
+func DefaultLockDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "miniopencode")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("miniopencode-%d", os.Getuid()))
+}
+
+// lock serializes resolving name on the configured server among instances
+// sharing LockDir, so concurrent launches find the session the first one
+// created instead of each creating their own. It waits until the lock is
+// free or ctx is done; the returned func releases it.
+//
+// The lock is an advisory lock on a file that is never removed, so the
+// system releases it when its holder exits, even after a crash, and there
+// is no stale file to take over.
+func (r Resolver) lock(ctx context.Context, name string) (func(), error) {
+	if r.LockDir == "" {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(r.LockDir, 0o700); err != nil {
+		return nil, err
+	}
+	s := r.Config.Server
+	key := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%s:%d\x00%s", s.BaseURL, s.Socket, s.Host, s.Port, name))
+	path := filepath.Join(r.LockDir, hex.EncodeToString(key[:8])+".lock")
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if locked {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, fmt.Errorf("waiting for session lock %s: %w", path, ctx.Err())
+		case <-time.After(lockPoll):
+		}
+	}
+	// Record the holder for anyone inspecting a lock that is taking long.
+	if f.Truncate(0) == nil {
+		f.WriteAt(fmt.Appendf(nil, "%d\n", os.Getpid()), 0)
+	}
+	// Closing the file releases the lock.
+	return func() { f.Close() }, nil
+}
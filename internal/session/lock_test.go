@@ -0,0 +1,89 @@
+package session
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+// slowClient is a stubClient safe for concurrent use whose listing is slow
+// enough for concurrent resolutions to overlap.
+type slowClient struct {
+	mu sync.Mutex
+	stubClient
+}
+
+func (c *slowClient) ListSessions(ctx context.Context) ([]client.Session, error) {
+	time.Sleep(20 * time.Millisecond)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]client.Session(nil), c.sessions...), nil
+}
+
+func (c *slowClient) CreateSession(ctx context.Context, title string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stubClient.CreateSession(ctx, title)
+}
+
+func TestConcurrentResolveCreatesOneSession(t *testing.T) {
+	sc := &slowClient{}
+	dir := t.TempDir()
+
+	ids := make([]string, 4)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := Resolver{Client: sc, Config: baseConfig(), Now: fixedNow, LockDir: dir}
+			id, err := r.Resolve(context.Background(), "my-project")
+			if err != nil {
+				t.Errorf("resolve: %v", err)
+			}
+			ids[i] = id
+		}()
+	}
+	wg.Wait()
+
+	if len(sc.created) != 1 {
+		t.Fatalf("expected one session to be created, got %v", sc.created)
+	}
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("instances resolved different sessions: %v", ids)
+		}
+	}
+}
+
+func TestResolveWaitsForHeldLock(t *testing.T) {
+	dir := t.TempDir()
+	r := Resolver{Client: &stubClient{}, Config: baseConfig(), Now: fixedNow, LockDir: dir}
+
+	unlock, err := r.lock(context.Background(), "daily")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := r.Resolve(ctx, "daily"); err == nil {
+		t.Fatalf("resolve should wait for a held lock")
+	}
+	unlock()
+
+	// The file stays behind, as it does after a crash; only the lock on it
+	// matters.
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected the lock file to remain, got %v", entries)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := r.Resolve(ctx, "daily"); err != nil {
+		t.Fatalf("a released lock should be taken at once: %v", err)
+	}
+}
@@ -3,9 +3,6 @@ package session
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -23,96 +20,103 @@ type Resolver struct {
 	Client Client
 	Config config.Config
 	Now    func() time.Time
+	// Dir is the working directory for the branch and cwd rotations;
+	// empty uses the process working directory.
+	Dir string
+	// Git returns the work tree root and branch for dir; nil runs git.
+	Git func(dir string) (root, branch string, err error)
+	// Usage caches session usage for limit checks; nil always counts
+	// every message.
+	Usage *UsageCache
+	// LockDir holds the lock files that keep concurrent instances from
+	// creating the same session twice (see DefaultLockDir); empty disables
+	// locking.
+	LockDir string
+	// Status, if set, reports slow steps such as summarizing a session
+	// before rolling over, for display while Resolve runs.
+	Status func(msg string)
 }
 
+func (r Resolver) status(format string, args ...any) {
+	if r.Status != nil {
+		r.Status(fmt.Sprintf(format, args...))
+	}
+}
+
+// Resolve returns the session for defaultSession: a rotation name (see
+// Rotation) or a session ID or title.
 func (r Resolver) Resolve(ctx context.Context, defaultSession string) (string, error) {
 	if defaultSession == "" {
 		return "", fmt.Errorf("no default session configured")
 	}
-	if defaultSession != "daily" {
-		sessions, err := r.Client.ListSessions(ctx)
-		if err != nil {
-			return "", err
-		}
-		for _, s := range sessions {
-			if s.ID == defaultSession {
-				return s.ID, nil
-			}
-		}
-		return r.Client.CreateSession(ctx, defaultSession)
+	unlock, err := r.lock(ctx, defaultSession)
+	if err != nil {
+		return "", err
 	}
-	return r.resolveDaily(ctx)
-}
-
-var dailyRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-daily-(\d+)$`)
+	defer unlock()
 
-func (r Resolver) resolveDaily(ctx context.Context) (string, error) {
-	now := r.Now
-	if now == nil {
-		now = time.Now
+	if rot, ok := ParseRotation(defaultSession); ok {
+		return r.resolveRotating(ctx, rot)
 	}
-	today := now()
-	datePrefix := today.Format("2006-01-02")
-
 	sessions, err := r.Client.ListSessions(ctx)
 	if err != nil {
 		return "", err
 	}
-
-	titleFormat := r.Config.Session.DailyTitleFormat
-	if titleFormat == "" {
-		titleFormat = "2006-01-02-daily-%d"
-	}
-	buildTitle := func(part int) string {
-		layout := titleFormat
-		if strings.Contains(layout, "2006-01-02") {
-			layout = strings.Replace(layout, "2006-01-02", "%s", 1)
-		}
-		return fmt.Sprintf(layout, datePrefix, part)
+	if s, ok := Match(sessions, defaultSession, r.Config.Session.Match); ok {
+		return s.ID, nil
 	}
+	return r.Client.CreateSession(ctx, defaultSession)
+}
 
-	var todays []client.Session
+// Match finds the session named by want: an exact ID, else an exact title,
+// else (for mode "prefix" or "fuzzy") a title starting with want, else (for
+// "fuzzy") a title containing want ignoring case. Among several sessions
+// matching at the same step, the most recently updated wins.
+func Match(sessions []client.Session, want, mode string) (client.Session, bool) {
 	for _, s := range sessions {
-		matches := dailyRe.FindStringSubmatch(s.Title)
-		if len(matches) != 3 {
-			continue
+		if s.ID == want {
+			return s, true
 		}
-		if matches[1] != datePrefix {
-			continue
-		}
-		todays = append(todays, client.Session{ID: s.ID, Title: s.Title, Part: atoi(matches[2])})
 	}
-
-	if len(todays) == 0 {
-		return r.Client.CreateSession(ctx, buildTitle(1))
+	steps := []func(title string) bool{
+		func(title string) bool { return title == want },
 	}
-
-	sort.Slice(todays, func(i, j int) bool { return todays[i].Part < todays[j].Part })
-	latest := todays[len(todays)-1]
-
-	underLimit, err := r.underLimit(ctx, latest.ID)
-	if err != nil {
-		return "", err
+	if mode == "prefix" || mode == "fuzzy" {
+		steps = append(steps, func(title string) bool { return strings.HasPrefix(title, want) })
+	}
+	if mode == "fuzzy" {
+		lower := strings.ToLower(want)
+		steps = append(steps, func(title string) bool { return strings.Contains(strings.ToLower(title), lower) })
+	}
+	for _, matches := range steps {
+		var best client.Session
+		found := false
+		for _, s := range sessions {
+			if matches(s.Title) && (!found || updated(s) > updated(best)) {
+				best, found = s, true
+			}
+		}
+		if found {
+			return best, true
+		}
 	}
-	if underLimit {
-		return latest.ID, nil
+	return client.Session{}, false
+}
+
+// updated returns when s last changed, in epoch milliseconds.
+func updated(s client.Session) float64 {
+	if s.Time == nil {
+		return 0
 	}
-	nextPart := latest.Part + 1
-	return r.Client.CreateSession(ctx, buildTitle(nextPart))
+	return max(s.Time.Updated, s.Time.Created)
 }
 
-func (r Resolver) underLimit(ctx context.Context, sessionID string) (bool, error) {
-	msgs, err := r.Client.ListMessages(ctx, sessionID)
+func (r Resolver) underLimit(ctx context.Context, s client.Session) (bool, error) {
+	usage, err := r.usage(ctx, s)
 	if err != nil {
 		return false, err
 	}
-	var totalTokens, totalMessages int
-	for _, m := range msgs {
-		totalMessages++
-		if m.Tokens != nil {
-			totalTokens += m.Tokens.Input + m.Tokens.Output + m.Tokens.Reasoning
-		}
-	}
+	totalTokens, totalMessages := usage.Tokens, usage.Messages
 
 	maxTokens := r.Config.Session.DailyMaxTokens
 	if maxTokens == 0 {
@@ -131,8 +135,3 @@ func (r Resolver) underLimit(ctx context.Context, sessionID string) (bool, error
 	}
 	return true, nil
 }
-
-func atoi(s string) int {
-	n, _ := strconv.Atoi(s)
-	return n
-}
@@ -145,3 +145,272 @@ func TestResolveDailyCreatesTodayWhenOnlyOldSessions(t *testing.T) {
 		t.Fatalf("expected new today part, got %s", id)
 	}
 }
+
+func TestResolveMatchesTitleInsteadOfCreating(t *testing.T) {
+	at := func(updated float64) *client.SessionTime { return &client.SessionTime{Created: 1, Updated: updated} }
+	sessions := []client.Session{
+		{ID: "ses-old", Title: "my-project", Time: at(100)},
+		{ID: "ses-new", Title: "my-project", Time: at(300)},
+		{ID: "ses-pre", Title: "my-project v2", Time: at(900)},
+		{ID: "ses-fz", Title: "Refactor My-Project API", Time: at(50)},
+	}
+	tests := []struct {
+		want, mode, id string
+	}{
+		{"my-project", "exact", "ses-new"},
+		{"my-project", "", "ses-new"},
+		{"my-proj", "exact", ""},
+		{"my-proj", "prefix", "ses-pre"},
+		{"MY-PROJECT api", "prefix", ""},
+		{"MY-PROJECT api", "fuzzy", "ses-fz"},
+		{"ses-old", "exact", "ses-old"},
+	}
+	for _, tt := range tests {
+		sc := &stubClient{sessions: sessions}
+		cfg := baseConfig()
+		cfg.Session.Match = tt.mode
+		r := Resolver{Client: sc, Config: cfg, Now: fixedNow}
+
+		id, err := r.Resolve(context.Background(), tt.want)
+		if err != nil {
+			t.Fatalf("resolve %q: %v", tt.want, err)
+		}
+		if tt.id == "" {
+			if len(sc.created) != 1 || sc.created[0] != tt.want {
+				t.Errorf("%q (%s): expected a new session, got %s", tt.want, tt.mode, id)
+			}
+			continue
+		}
+		if id != tt.id || len(sc.created) != 0 {
+			t.Errorf("%q (%s): expected %s without creating, got %s (created %v)", tt.want, tt.mode, tt.id, id, sc.created)
+		}
+	}
+}
+
+func TestResolveDailyHonorsCustomTitleFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		existing []string
+		wantID   string
+		created  string
+	}{
+		{"team-2006-01-02 (part %d)", []string{"team-2026-01-17 (part 1)", "team-2026-01-17 (part 2)", "team-2026-01-16 (part 9)"}, "t1", ""},
+		{"%d. Jan 2 2006 notes", []string{"3. Jan 17 2026 notes"}, "t0", ""},
+		{"daily/20060102/%d", []string{"daily/20260116/1"}, "", "daily/20260117/1"},
+		{"team-2006-01-02 (part %d)", []string{"team-2026-01-17 (part x)", "team-2026-01-17 (part 01)"}, "", "team-2026-01-17 (part 1)"},
+		{"'sprint5'-2006-01-02-%d", []string{"sprint0-2026-01-17-1", "sprint5-2026-01-17-1"}, "t1", ""},
+		{"'v2' 2006-01-02 #%d", nil, "", "v2 2026-01-17 #1"},
+		{"'Mon''s log' Jan 2 %d", nil, "", "Mon's log Jan 17 1"},
+	}
+	for _, tt := range tests {
+		sc := &stubClient{messages: map[string][]client.Message{}}
+		for i, title := range tt.existing {
+			id := "t" + string(rune('0'+i))
+			sc.sessions = append(sc.sessions, client.Session{ID: id, Title: title})
+			sc.messages[id] = nil
+		}
+		cfg := baseConfig()
+		cfg.Session.DailyTitleFormat = tt.format
+		r := Resolver{Client: sc, Config: cfg, Now: fixedNow}
+
+		id, err := r.Resolve(context.Background(), "daily")
+		if err != nil {
+			t.Fatalf("%s: resolve: %v", tt.format, err)
+		}
+		if tt.created != "" {
+			if len(sc.created) != 1 || sc.created[0] != tt.created {
+				t.Errorf("%s: expected to create %q, created %v", tt.format, tt.created, sc.created)
+			}
+			continue
+		}
+		if id != tt.wantID || len(sc.created) != 0 {
+			t.Errorf("%s: expected %s, got %s (created %v)", tt.format, tt.wantID, id, sc.created)
+		}
+	}
+}
+
+func TestResolveDailyRollsOverWithCustomFormat(t *testing.T) {
+	sc := &stubClient{
+		sessions: []client.Session{{ID: "p2", Title: "team-2026-01-17 (part 2)"}},
+		messages: map[string][]client.Message{"p2": {{Tokens: &client.TokenUsage{Input: 20}}}},
+	}
+	cfg := baseConfig()
+	cfg.Session.DailyTitleFormat = "team-2006-01-02 (part %d)"
+	r := Resolver{Client: sc, Config: cfg, Now: fixedNow}
+
+	id, err := r.Resolve(context.Background(), "daily")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if id != "new-team-2026-01-17 (part 3)" {
+		t.Fatalf("expected part 3, got %s", id)
+	}
+}
+
+func TestResolveTimeRotations(t *testing.T) {
+	tests := []struct {
+		policy   string
+		existing string
+		want     string
+	}{
+		{"weekly", "", "2026-01-12-weekly-1"},
+		{"weekly", "2026-01-12-weekly-2", "new-2026-01-12-weekly-3"},
+		{"weekly", "2026-01-05-weekly-4", "new-2026-01-12-weekly-1"},
+		{"monthly", "", "2026-01-monthly-1"},
+		{"monthly", "2026-01-monthly-1", "new-2026-01-monthly-2"},
+	}
+	for _, tt := range tests {
+		sc := &stubClient{messages: map[string][]client.Message{"old": {{Tokens: &client.TokenUsage{Input: 20}}}}}
+		if tt.existing != "" {
+			sc.sessions = []client.Session{{ID: "old", Title: tt.existing}}
+		}
+		r := Resolver{Client: sc, Config: baseConfig(), Now: fixedNow}
+
+		id, err := r.Resolve(context.Background(), tt.policy)
+		if err != nil {
+			t.Fatalf("%s: resolve: %v", tt.policy, err)
+		}
+		if tt.existing == "" && (len(sc.created) != 1 || sc.created[0] != tt.want) {
+			t.Errorf("%s: expected %q to be created, got %v", tt.policy, tt.want, sc.created)
+		} else if tt.existing != "" && id != tt.want {
+			t.Errorf("%s after %q: expected %q, got %q", tt.policy, tt.existing, tt.want, id)
+		}
+	}
+}
+
+func TestResolveBranchRotation(t *testing.T) {
+	sc := &stubClient{
+		sessions: []client.Session{
+			{ID: "main-1", Title: "app@main-1"},
+			{ID: "feat-1", Title: "app@feature/x-1"},
+		},
+		messages: map[string][]client.Message{"feat-1": {{Tokens: &client.TokenUsage{Input: 1}}}},
+	}
+	git := func(dir string) (string, string, error) {
+		if dir != "/src/app/sub" {
+			t.Fatalf("unexpected dir %q", dir)
+		}
+		return "/src/app", "feature/x", nil
+	}
+	r := Resolver{Client: sc, Config: baseConfig(), Now: fixedNow, Dir: "/src/app/sub", Git: git}
+
+	id, err := r.Resolve(context.Background(), "branch")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if id != "feat-1" {
+		t.Fatalf("expected the branch's session, got %s", id)
+	}
+
+	r.Git = func(string) (string, string, error) { return "", "", errors.New("not a git work tree") }
+	if _, err := r.Resolve(context.Background(), "branch"); err == nil {
+		t.Fatalf("branch rotation outside a work tree should fail")
+	}
+}
+
+func TestResolveCwdRotationWithCustomFormat(t *testing.T) {
+	sc := &stubClient{
+		sessions: []client.Session{{ID: "w1", Title: "work: proj (1)"}},
+		messages: map[string][]client.Message{"w1": {{Tokens: &client.TokenUsage{Input: 20}}}},
+	}
+	cfg := baseConfig()
+	cfg.Session.TitleFormats = map[string]string{"cwd": "work: {dir} (%d)"}
+	r := Resolver{Client: sc, Config: cfg, Now: fixedNow, Dir: "/home/me/proj"}
+
+	id, err := r.Resolve(context.Background(), "cwd")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if id != "new-work: proj (2)" {
+		t.Fatalf("expected rollover to part 2, got %s", id)
+	}
+}
+
+type promptingClient struct {
+	stubClient
+	summary  error
+	waited   []string
+	deadline bool
+	seeded   map[string]client.PromptInput
+}
+
+func (p *promptingClient) PromptWait(ctx context.Context, sessionID string, input client.PromptInput) (*client.PromptResult, error) {
+	p.waited = append(p.waited, sessionID)
+	_, p.deadline = ctx.Deadline()
+	if p.summary != nil {
+		return nil, p.summary
+	}
+	return &client.PromptResult{Text: " Working on the parser.\n"}, nil
+}
+
+func (p *promptingClient) SendPromptAsync(ctx context.Context, sessionID string, input client.PromptInput) error {
+	if p.seeded == nil {
+		p.seeded = map[string]client.PromptInput{}
+	}
+	p.seeded[sessionID] = input
+	return nil
+}
+
+func TestRolloverCarriesSummary(t *testing.T) {
+	pc := &promptingClient{stubClient: stubClient{
+		sessions: []client.Session{{ID: "ses-1", Title: "2026-01-17-daily-1"}},
+		messages: map[string][]client.Message{"ses-1": {{Tokens: &client.TokenUsage{Input: 20}}}},
+	}}
+	cfg := baseConfig()
+	cfg.Session.CarrySummary = []string{"daily"}
+	cfg.Defaults.Agent = "build"
+	var status []string
+	r := Resolver{Client: pc, Config: cfg, Now: fixedNow, Status: func(msg string) { status = append(status, msg) }}
+
+	id, err := r.Resolve(context.Background(), "daily")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(pc.waited) != 1 || pc.waited[0] != "ses-1" {
+		t.Fatalf("expected the previous part to be summarized, got %v", pc.waited)
+	}
+	if !pc.deadline {
+		t.Fatalf("the summary request must be bounded by a deadline")
+	}
+	if len(status) != 2 || status[1] != `carried over a summary of "2026-01-17-daily-1"` {
+		t.Fatalf("unexpected status reports: %q", status)
+	}
+	seed, ok := pc.seeded[id]
+	if !ok || !seed.NoReply || seed.Agent != "build" {
+		t.Fatalf("expected the new part to be seeded without a reply, got %+v", pc.seeded)
+	}
+	want := "Summary of the previous session \"2026-01-17-daily-1\":\n\nWorking on the parser."
+	if seed.Parts[0].Text != want {
+		t.Fatalf("unexpected seed text %q", seed.Parts[0].Text)
+	}
+}
+
+func TestRolloverSummaryIsOptional(t *testing.T) {
+	newClient := func() *promptingClient {
+		return &promptingClient{stubClient: stubClient{
+			sessions: []client.Session{{ID: "ses-1", Title: "2026-01-12-weekly-1"}},
+			messages: map[string][]client.Message{"ses-1": {{Tokens: &client.TokenUsage{Input: 20}}}},
+		}}
+	}
+	cfg := baseConfig()
+	cfg.Session.CarrySummary = []string{"daily"}
+
+	pc := newClient()
+	if _, err := (Resolver{Client: pc, Config: cfg, Now: fixedNow}).Resolve(context.Background(), "weekly"); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(pc.waited) != 0 || len(pc.seeded) != 0 {
+		t.Fatalf("weekly is not configured to carry a summary: %v %v", pc.waited, pc.seeded)
+	}
+
+	cfg.Session.CarrySummary = []string{"weekly"}
+	pc = newClient()
+	pc.summary = errors.New("model unavailable")
+	id, err := Resolver{Client: pc, Config: cfg, Now: fixedNow}.Resolve(context.Background(), "weekly")
+	if err != nil || id != "new-2026-01-12-weekly-2" {
+		t.Fatalf("a failed summary must not block rollover: %q %v", id, err)
+	}
+	if len(pc.seeded) != 0 {
+		t.Fatalf("nothing should be seeded without a summary: %v", pc.seeded)
+	}
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+// Rotation names a session rotation policy, selected by using its name as
+// the default session. Each rotation keeps a series of numbered parts per
+// period (day, week, month) or per location (git branch, directory), and
+// moves to the next part when the session limits are exceeded.
+type Rotation string
+
+const (
+	RotationDaily   Rotation = "daily"
+	RotationWeekly  Rotation = "weekly"
+	RotationMonthly Rotation = "monthly"
+	RotationBranch  Rotation = "branch"
+	RotationCwd     Rotation = "cwd"
+)
+
+// DefaultTitleFormats are the title formats used when none is configured.
+// Daily, weekly and monthly formats are Go time layouts rendered at the start
+// of the period (weeks start on Monday). Branch formats may use {repo} and
+// {branch}, cwd formats {dir} and {path}.
+var DefaultTitleFormats = map[Rotation]string{
+	RotationDaily:   "2006-01-02-daily-%d",
+	RotationWeekly:  "2006-01-02-weekly-%d",
+	RotationMonthly: "2006-01-monthly-%d",
+	RotationBranch:  "{repo}@{branch}-%d",
+	RotationCwd:     "{path} #%d",
+}
+
+// ParseRotation reports whether name selects a rotation.
+func ParseRotation(name string) (Rotation, bool) {
+	r := Rotation(name)
+	_, ok := DefaultTitleFormats[r]
+	return r, ok
+}
+
+// period returns the start of the rotation period containing now; the zero
+// time for rotations that are not time-based.
+func (rot Rotation) period(now time.Time) time.Time {
+	y, m, d := now.Date()
+	switch rot {
+	case RotationDaily:
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	case RotationWeekly:
+		offset := (int(now.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, now.Location())
+	case RotationMonthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Time{}
+}
+
+// template builds the title template of rot, resolving its placeholders.
+func (r Resolver) template(rot Rotation) (titleTemplate, error) {
+	format := r.Config.Session.TitleFormats[string(rot)]
+	if format == "" && rot == RotationDaily {
+		format = r.Config.Session.DailyTitleFormat
+	}
+	if format == "" {
+		format = DefaultTitleFormats[rot]
+	}
+	switch rot {
+	case RotationBranch:
+		dir, err := r.workdir()
+		if err != nil {
+			return titleTemplate{}, err
+		}
+		gitInfo := r.Git
+		if gitInfo == nil {
+			gitInfo = gitBranch
+		}
+		root, branch, err := gitInfo(dir)
+		if err != nil {
+			return titleTemplate{}, fmt.Errorf("branch rotation: %w", err)
+		}
+		return newTitleTemplate(format, false, map[string]string{"repo": filepath.Base(root), "branch": branch}), nil
+	case RotationCwd:
+		dir, err := r.workdir()
+		if err != nil {
+			return titleTemplate{}, err
+		}
+		return newTitleTemplate(format, false, map[string]string{"dir": filepath.Base(dir), "path": dir}), nil
+	}
+	return newTitleTemplate(format, true, nil), nil
+}
+
+func (r Resolver) workdir() (string, error) {
+	if r.Dir != "" {
+		return r.Dir, nil
+	}
+	return os.Getwd()
+}
+
+// gitBranch returns the top-level directory and current branch of the git
+// work tree containing dir.
+func gitBranch(dir string) (root, branch string, err error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("%s is not in a git work tree", dir)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("unexpected git output %q", out)
+	}
+	return lines[0], lines[1], nil
+}
+
+// resolveRotating returns the latest part of rot for the current period or
+// location, creating the first or next part when there is none or the
+// latest exceeds the session limits.
+func (r Resolver) resolveRotating(ctx context.Context, rot Rotation) (string, error) {
+	now := r.Now
+	if now == nil {
+		now = time.Now
+	}
+	at := rot.period(now())
+
+	tmpl, err := r.template(rot)
+	if err != nil {
+		return "", err
+	}
+	sessions, err := r.Client.ListSessions(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var latest client.Session
+	latestPart := 0
+	for _, s := range sessions {
+		if part, ok := tmpl.part(s.Title, at); ok && part > latestPart {
+			latest, latestPart = s, part
+		}
+	}
+	if latestPart == 0 {
+		return r.Client.CreateSession(ctx, tmpl.title(at, 1))
+	}
+
+	underLimit, err := r.underLimit(ctx, latest)
+	if err != nil {
+		return "", err
+	}
+	if underLimit {
+		return latest.ID, nil
+	}
+
+	var summary string
+	if slices.Contains(r.Config.Session.CarrySummary, string(rot)) {
+		summary = r.summarize(ctx, latest)
+	}
+	id, err := r.Client.CreateSession(ctx, tmpl.title(at, latestPart+1))
+	if err != nil || summary == "" {
+		return id, err
+	}
+	r.seed(ctx, id, latest.Title, summary)
+	return id, nil
+}
@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"miniopencode/internal/client"
+)
+
+// Prompter is implemented by clients that can run a prompt to completion.
+// Rotations carry a summary across rollover only when the Client is one.
+type Prompter interface {
+	PromptWait(ctx context.Context, sessionID string, input client.PromptInput) (*client.PromptResult, error)
+	SendPromptAsync(ctx context.Context, sessionID string, input client.PromptInput) error
+}
+
+// summaryTimeout bounds the summary request, so a slow or stuck model delays
+// startup, and other instances waiting on the session lock, only so long.
+const summaryTimeout = 2 * time.Minute
+
+const summaryPrompt = `This session has reached its size limit and work will continue in a new session that cannot see this one. ` +
+	`Summarize it for that session: the goal, decisions made, current state of the work, relevant files, and open questions or next steps. ` +
+	`Reply with the summary only.`
+
+// summarize asks the model for a summary of s. Rolling over must not fail
+// because of it, so errors are reported and yield an empty summary.
+func (r Resolver) summarize(ctx context.Context, s client.Session) string {
+	p, ok := r.Client.(Prompter)
+	if !ok {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+	r.status("summarizing %q before rolling over…", s.Title)
+	res, err := p.PromptWait(ctx, s.ID, r.promptInput(summaryPrompt, false))
+	if err != nil {
+		log.Printf("session: summarize %s: %v", s.ID, err)
+		r.status("could not summarize %q: %v", s.Title, err)
+		return ""
+	}
+	return strings.TrimSpace(res.Text)
+}
+
+// seed adds summary to sessionID as context without asking for a reply.
+func (r Resolver) seed(ctx context.Context, sessionID, previous, summary string) {
+	text := fmt.Sprintf("Summary of the previous session %q:\n\n%s", previous, summary)
+	if err := r.Client.(Prompter).SendPromptAsync(ctx, sessionID, r.promptInput(text, true)); err != nil {
+		log.Printf("session: seed %s with summary: %v", sessionID, err)
+		r.status("could not carry over the summary of %q: %v", previous, err)
+		return
+	}
+	r.status("carried over a summary of %q", previous)
+}
+
+func (r Resolver) promptInput(text string, noReply bool) client.PromptInput {
+	d := r.Config.Defaults
+	input := client.PromptInput{
+		Parts:   []client.InputPart{{Type: "text", Text: text}},
+		Agent:   d.Agent,
+		NoReply: noReply,
+	}
+	if d.ProviderID != "" && d.ModelID != "" {
+		input.Model = &client.ModelRef{ProviderID: d.ProviderID, ModelID: d.ModelID}
+	}
+	return input
+}
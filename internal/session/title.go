@@ -0,0 +1,108 @@
+package session
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// titleTemplate builds and recognizes rotated session titles. A format holds
+// a single %d for the part number and may reference {name} placeholders. For
+// time-based rotations the rest of the format is a Go time layout rendered
+// at the start of the period, such as "2006-01-02-daily-%d" or
+// "team-2006-01-02 (part %d)", so the date may appear anywhere. Text in
+// single quotes is kept as is, so "'sprint5'-2006-01-02-%d" keeps its 5.
+type titleTemplate struct {
+	before, after string
+	layout        bool
+	vars          map[string]string
+}
+
+func newTitleTemplate(format string, layout bool, vars map[string]string) titleTemplate {
+	before, after, found := strings.Cut(format, "%d")
+	if !found {
+		// Without a part number, append one so rollover stays possible.
+		before, after = format+"-", ""
+	}
+	return titleTemplate{before: before, after: after, layout: layout, vars: vars}
+}
+
+// render formats s at t, substituting placeholders after the time layout so
+// their values are never mistaken for layout elements.
+func (t titleTemplate) render(s string, at time.Time) string {
+	var b strings.Builder
+	for s != "" {
+		open := strings.IndexByte(s, '{')
+		end := strings.IndexByte(s[max(open, 0):], '}')
+		literal := s
+		if open >= 0 && end >= 0 {
+			literal = s[:open]
+		}
+		if t.layout {
+			literal = formatLayout(literal, at)
+		}
+		b.WriteString(literal)
+		if open < 0 || end < 0 {
+			break
+		}
+		name := s[open+1 : open+end]
+		if v, ok := t.vars[name]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(s[open : open+end+1])
+		}
+		s = s[open+end+1:]
+	}
+	return b.String()
+}
+
+// formatLayout renders the Go time layout at t. Text between single quotes
+// is copied without the quotes instead of being read as layout elements, and
+// '' stands for a quote.
+func formatLayout(layout string, at time.Time) string {
+	var b, pending strings.Builder
+	flush := func() {
+		b.WriteString(at.Format(pending.String()))
+		pending.Reset()
+	}
+	quoted := false
+	for i := 0; i < len(layout); i++ {
+		c := layout[i]
+		switch {
+		case c != '\'':
+			if quoted {
+				b.WriteByte(c)
+			} else {
+				pending.WriteByte(c)
+			}
+		case i+1 < len(layout) && layout[i+1] == '\'':
+			flush()
+			b.WriteByte('\'')
+			i++
+		default:
+			flush()
+			quoted = !quoted
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// title returns the title of part for the period starting at.
+func (t titleTemplate) title(at time.Time, part int) string {
+	return t.render(t.before, at) + strconv.Itoa(part) + t.render(t.after, at)
+}
+
+// part returns the part number if title was built for the period starting at.
+func (t titleTemplate) part(title string, at time.Time) (int, bool) {
+	before, after := t.render(t.before, at), t.render(t.after, at)
+	if len(title) <= len(before)+len(after) || !strings.HasPrefix(title, before) || !strings.HasSuffix(title, after) {
+		return 0, false
+	}
+	digits := title[len(before) : len(title)-len(after)]
+	n, err := strconv.Atoi(digits)
+	if err != nil || n < 1 || strconv.Itoa(n) != digits {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,203 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"miniopencode/internal/client"
+)
+
+// usageWindow is how many recent messages are fetched to bring a cached
+// usage up to date before falling back to a full scan.
+const usageWindow = 50
+
+// RecentLister is implemented by clients that can fetch only the newest
+// messages of a session; without it every stale usage is fully rescanned.
+type RecentLister interface {
+	RecentMessages(ctx context.Context, sessionID string, limit int) ([]client.Message, error)
+}
+
+// Usage is the message count and token total of a session as of its last
+// message.
+type Usage struct {
+	Messages int    `json:"messages"`
+	Tokens   int    `json:"tokens"`
+	LastID   string `json:"last_id"`
+	// LastTokens are the tokens of LastID, which keep growing while it is
+	// being answered.
+	LastTokens int `json:"last_tokens"`
+	// Updated is the session's update time when this was counted; while it
+	// is unchanged the usage needs no requests at all.
+	Updated float64 `json:"updated"`
+}
+
+// UsageCache keeps session usage across runs so limit checks need not
+// download whole histories. A nil *UsageCache caches nothing.
+type UsageCache struct {
+	path     string
+	mu       sync.Mutex
+	sessions map[string]Usage
+}
+
+// DefaultUsageCachePath returns the cache file under the user cache
+// directory, or "" if there is none.
+func DefaultUsageCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "miniopencode", "usage.json")
+}
+
+// LoadUsageCache reads the cache at path. A missing or unreadable file
+// starts an empty cache; an empty path keeps it in memory only.
+func LoadUsageCache(path string) *UsageCache {
+	c := &UsageCache{path: path, sessions: map[string]Usage{}}
+	if path == "" {
+		return c
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		if json.Unmarshal(data, &c.sessions) != nil || c.sessions == nil {
+			c.sessions = map[string]Usage{}
+		}
+	}
+	return c
+}
+
+func (c *UsageCache) get(sessionID string) (Usage, bool) {
+	if c == nil {
+		return Usage{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, ok := c.sessions[sessionID]
+	return u, ok
+}
+
+func (c *UsageCache) put(sessionID string, u Usage) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions[sessionID] = u
+}
+
+// Observe updates the usage of a cached session from a message.updated
+// event. Updates to messages older than the last one cannot be applied, so
+// they drop the session from the cache.
+func (c *UsageCache) Observe(info client.MessageInfo) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u, ok := c.sessions[info.SessionID]
+	if !ok {
+		return
+	}
+	tokens := 0
+	if t := info.Tokens; t != nil {
+		tokens = t.Input + t.Output + t.Reasoning
+	}
+	switch {
+	case info.ID == u.LastID:
+		u.Tokens += tokens - u.LastTokens
+	case info.ID > u.LastID:
+		u.Messages++
+		u.Tokens += tokens
+		u.LastID = info.ID
+	default:
+		delete(c.sessions, info.SessionID)
+		return
+	}
+	u.LastTokens = tokens
+	c.sessions[info.SessionID] = u
+}
+
+// Save writes the cache to its file, replacing it atomically.
+func (c *UsageCache) Save() error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.Marshal(c.sessions)
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), ".usage-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// usage returns the usage of s, from the cache when s has not changed since,
+// by counting only the messages after the cached last one when it is among
+// the most recent, and by scanning the whole history otherwise.
+func (r Resolver) usage(ctx context.Context, s client.Session) (Usage, error) {
+	var updated float64
+	if s.Time != nil {
+		updated = s.Time.Updated
+	}
+	cached, ok := r.Usage.get(s.ID)
+	if ok && updated != 0 && cached.Updated == updated {
+		return cached, nil
+	}
+
+	if lister, canList := r.Client.(RecentLister); ok && canList && cached.LastID != "" {
+		msgs, err := lister.RecentMessages(ctx, s.ID, usageWindow)
+		if err != nil {
+			return Usage{}, err
+		}
+		for i, m := range msgs {
+			if m.ID != cached.LastID {
+				continue
+			}
+			u := cached
+			u.Tokens -= u.LastTokens
+			u.Messages--
+			u.count(msgs[i:])
+			u.Updated = updated
+			r.Usage.put(s.ID, u)
+			return u, nil
+		}
+	}
+
+	msgs, err := r.Client.ListMessages(ctx, s.ID)
+	if err != nil {
+		return Usage{}, err
+	}
+	u := Usage{Updated: updated}
+	u.count(msgs)
+	r.Usage.put(s.ID, u)
+	return u, nil
+}
+
+// count adds msgs, the messages after the current last one, to u.
+func (u *Usage) count(msgs []client.Message) {
+	for _, m := range msgs {
+		tokens := 0
+		if m.Tokens != nil {
+			tokens = m.Tokens.Input + m.Tokens.Output + m.Tokens.Reasoning
+		}
+		u.Messages++
+		u.Tokens += tokens
+		u.LastID, u.LastTokens = m.ID, tokens
+	}
+}
@@ -0,0 +1,83 @@
+package session
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"miniopencode/internal/client"
+)
+
+type recentClient struct {
+	stubClient
+	full, recent int
+}
+
+func (c *recentClient) ListMessages(ctx context.Context, sessionID string) ([]client.Message, error) {
+	c.full++
+	return c.stubClient.ListMessages(ctx, sessionID)
+}
+
+func (c *recentClient) RecentMessages(ctx context.Context, sessionID string, limit int) ([]client.Message, error) {
+	c.recent++
+	msgs := c.messages[sessionID]
+	return msgs[max(len(msgs)-limit, 0):], nil
+}
+
+func tokens(n int) *client.TokenUsage { return &client.TokenUsage{Input: n} }
+
+func TestUsageCountsDeltasAndSkipsUnchangedSessions(t *testing.T) {
+	rc := &recentClient{stubClient: stubClient{messages: map[string][]client.Message{
+		"s": {{ID: "m1", Tokens: tokens(2)}, {ID: "m2", Tokens: tokens(3)}},
+	}}}
+	r := Resolver{Client: rc, Usage: LoadUsageCache("")}
+	s := client.Session{ID: "s", Time: &client.SessionTime{Updated: 1}}
+
+	if u, _ := r.usage(context.Background(), s); u.Tokens != 5 || u.Messages != 2 || rc.full != 1 {
+		t.Fatalf("first count should scan fully: %+v full=%d", u, rc.full)
+	}
+	if u, _ := r.usage(context.Background(), s); u.Tokens != 5 || rc.full != 1 || rc.recent != 0 {
+		t.Fatalf("unchanged session should come from the cache: %+v", u)
+	}
+
+	// m2 finished answering and m3 was added.
+	rc.messages["s"][1].Tokens = tokens(10)
+	rc.messages["s"] = append(rc.messages["s"], client.Message{ID: "m3", Tokens: tokens(1)})
+	s.Time.Updated = 2
+	if u, _ := r.usage(context.Background(), s); u.Tokens != 13 || u.Messages != 3 || rc.full != 1 || rc.recent != 1 {
+		t.Fatalf("changed session should fetch only recent messages: %+v full=%d recent=%d", u, rc.full, rc.recent)
+	}
+
+	// The cached last message is gone (e.g. reverted): fall back to a full scan.
+	rc.messages["s"] = []client.Message{{ID: "m1", Tokens: tokens(2)}}
+	s.Time.Updated = 3
+	if u, _ := r.usage(context.Background(), s); u.Tokens != 2 || u.Messages != 1 || rc.full != 2 {
+		t.Fatalf("stale cache should rescan: %+v full=%d", u, rc.full)
+	}
+}
+
+func TestUsageCacheObservesEventsAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "usage.json")
+	c := LoadUsageCache(path)
+	c.put("s", Usage{Messages: 2, Tokens: 5, LastID: "m2", LastTokens: 3})
+
+	c.Observe(client.MessageInfo{ID: "m2", SessionID: "s", Tokens: &client.Tokens{Input: 3, Output: 4}})
+	c.Observe(client.MessageInfo{ID: "m3", SessionID: "s", Tokens: &client.Tokens{Input: 1}})
+	c.Observe(client.MessageInfo{ID: "m9", SessionID: "other"})
+	if err := c.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	u, ok := LoadUsageCache(path).get("s")
+	if !ok || u.Messages != 3 || u.Tokens != 10 || u.LastID != "m3" || u.LastTokens != 1 {
+		t.Fatalf("unexpected usage after events: %+v", u)
+	}
+	if _, ok := LoadUsageCache(path).get("other"); ok {
+		t.Fatalf("uncached sessions must not be added from events")
+	}
+
+	c.Observe(client.MessageInfo{ID: "m1", SessionID: "s"})
+	if _, ok := c.get("s"); ok {
+		t.Fatalf("an update to an older message should drop the session")
+	}
+}
@@ -2,15 +2,43 @@ package tui
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"os"
 
 	"miniopencode/internal/client"
 	"miniopencode/internal/config"
 	"miniopencode/internal/session"
 )
 
-func Run(ctx context.Context, cfg config.Config) error {
-	cli := client.New(client.Config{Host: cfg.Server.Host, Port: cfg.Server.Port})
-	resolver := session.Resolver{Client: cli, Config: cfg}
+// serverLabel names the server cc connects to: its Unix socket, its base
+// URL, or host:port.
+func serverLabel(cc client.Config) string {
+	switch {
+	case cc.Socket != "":
+		return "unix:" + cc.Socket
+	case cc.BaseURL != "":
+		return cc.BaseURL
+	}
+	return fmt.Sprintf("%s:%d", cc.Host, cc.Port)
+}
+
+// Run starts the TUI. report and opts describe how cfg was loaded; its files
+// are watched and safe changes applied live.
+func Run(ctx context.Context, cfg config.Config, report config.Report, opts config.Options) error {
+	cc, err := cfg.Server.ClientConfig()
+	if err != nil {
+		return err
+	}
+	cli := client.New(cc)
+	usage := session.LoadUsageCache(session.DefaultUsageCachePath())
+	// Resolving runs before the TUI is up, so slow steps are reported on
+	// stderr; the last one is kept for the status bar.
+	var resolveStatus string
+	resolver := session.Resolver{Client: cli, Config: cfg, Usage: usage, LockDir: session.DefaultLockDir(), Status: func(msg string) {
+		fmt.Fprintln(os.Stderr, msg)
+		resolveStatus = msg
+	}}
 
 	defaultSession := cfg.Session.DefaultSession
 	if defaultSession == "" {
@@ -21,7 +49,7 @@ func Run(ctx context.Context, cfg config.Config) error {
 		return err
 	}
 
-	streamer := &Streamer{Client: cli, Events: make(chan Chunk, 64), Errors: make(chan error, 1)}
+	streamer := &Streamer{Client: cli, Usage: usage, Events: make(chan Chunk, 64), Errors: make(chan error, 1)}
 	streamer.Start(ctx)
 
 	uiCfg := UIConfig{
@@ -34,17 +62,30 @@ func Run(ctx context.Context, cfg config.Config) error {
 	}
 	promptCfg := PromptConfig{Agent: cfg.Defaults.Agent, ProviderID: cfg.Defaults.ProviderID, ModelID: cfg.Defaults.ModelID}
 
+	applyTheme(cfg.Theme)
 	m := NewModel(uiCfg)
+	m.keys = KeyMapFromConfig(cfg.Keys)
+	m.cfg = cfg
+	if report.Path != "" {
+		var extra []string
+		if report.ProjectPath != "" {
+			extra = append(extra, report.ProjectPath)
+		}
+		m.watcher = newConfigWatcher(report.Path, opts, extra...)
+	}
+	m.notice = resolveStatus
 	m.streamer = streamer
 	m.sessionID = sessionID
 	m.promptCfg = promptCfg
 	m.chunkCh = streamer.Events
 	m.errCh = streamer.Errors
 	m.maxOutputLines = cfg.UI.MaxOutputLines
-	m.serverHost = cfg.Server.Host
-	m.serverPort = cfg.Server.Port
+	m.server = serverLabel(cc)
 
 	p := newProgram(m)
 	_, err = p.Run()
+	if err := usage.Save(); err != nil {
+		log.Printf("tui: save usage cache: %v", err)
+	}
 	return err
 }
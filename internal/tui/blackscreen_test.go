@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"miniopencode/internal/client"
 )
 
 func TestInitialViewNotBlank(t *testing.T) {
@@ -81,8 +83,7 @@ func TestStatusBarVisible(t *testing.T) {
 	m.width = 100
 	m.height = 30
 	m.sessionID = "test-session"
-	m.serverHost = "localhost"
-	m.serverPort = 4096
+	m.server = serverLabel(client.Config{Host: "localhost", Port: 4096})
 	m.applySizes()
 
 	view := m.View()
@@ -96,6 +97,17 @@ func TestStatusBarVisible(t *testing.T) {
 	if !strings.Contains(view, "localhost:4096") {
 		t.Error("status bar should contain server info")
 	}
+
+	m.server = serverLabel(client.Config{Host: "localhost", Port: 4096, Socket: "/run/oc.sock"})
+	if view := m.View(); !strings.Contains(view, "unix:/run/oc.sock") {
+		t.Error("status bar should show the unix socket instead of host:port")
+	}
+
+	m.server = serverLabel(client.Config{Host: "localhost", Port: 4096, BaseURL: "https://oc.example/api"})
+	view = m.View()
+	if !strings.Contains(view, "https://oc.example/api") || strings.Contains(view, "localhost:4096") {
+		t.Error("status bar should show the base URL instead of host:port")
+	}
 }
 
 func TestBordersVisible(t *testing.T) {
@@ -0,0 +1,97 @@
+package tui
+
+import "strings"
+
+// CodeBlock is a fenced code block found in answer markdown.
+type CodeBlock struct {
+	Lang string
+	Code string
+}
+
+// FirstLine returns the first non-blank line of the block, used when listing.
+func (b CodeBlock) FirstLine() string {
+	for _, line := range strings.Split(b.Code, "\n") {
+		if strings.TrimSpace(line) != "" {
+			return strings.TrimSpace(line)
+		}
+	}
+	return ""
+}
+
+// extractCodeBlocks returns the fenced (``` or ~~~) code blocks of md in
+// order. A block that is still open at the end of the text (e.g. while the
+// answer is streaming) runs to the end.
+func extractCodeBlocks(md string) []CodeBlock {
+	var blocks []CodeBlock
+	var (
+		open   bool
+		fence  string
+		indent int
+		cur    CodeBlock
+		body   []string
+	)
+	for _, line := range strings.Split(md, "\n") {
+		if !open {
+			f, n, info, ok := parseFence(line)
+			if !ok {
+				continue
+			}
+			open, fence, indent = true, f, n
+			cur = CodeBlock{Lang: infoLang(info)}
+			body = body[:0]
+			continue
+		}
+		if f, _, info, ok := parseFence(line); ok && info == "" && f[0] == fence[0] && len(f) >= len(fence) {
+			cur.Code = strings.Join(body, "\n")
+			blocks = append(blocks, cur)
+			open = false
+			continue
+		}
+		body = append(body, stripIndent(line, indent))
+	}
+	if open {
+		cur.Code = strings.Join(body, "\n")
+		blocks = append(blocks, cur)
+	}
+	return blocks
+}
+
+// parseFence reports whether line opens or closes a fence, returning the fence
+// run, its indentation and the trailing info string.
+func parseFence(line string) (fence string, indent int, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	indent = len(line) - len(trimmed)
+	if indent > 3 || len(trimmed) < 3 {
+		return "", 0, "", false
+	}
+	ch := trimmed[0]
+	if ch != '`' && ch != '~' {
+		return "", 0, "", false
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == ch {
+		n++
+	}
+	if n < 3 {
+		return "", 0, "", false
+	}
+	info = strings.TrimSpace(trimmed[n:])
+	if ch == '`' && strings.Contains(info, "`") {
+		return "", 0, "", false
+	}
+	return trimmed[:n], indent, info, true
+}
+
+func infoLang(info string) string {
+	if fields := strings.Fields(info); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
+
+func stripIndent(line string, n int) string {
+	for i := 0; i < n && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
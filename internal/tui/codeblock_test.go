@@ -0,0 +1,38 @@
+package tui
+
+import "testing"
+
+func TestExtractCodeBlocks(t *testing.T) {
+	md := "Intro\n\n```go\npackage main\n\nfunc main() {}\n```\n\nThen:\n\n~~~\nls -la\n~~~\n"
+	blocks := extractCodeBlocks(md)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 blocks, got %d", len(blocks))
+	}
+	if blocks[0].Lang != "go" || blocks[0].Code != "package main\n\nfunc main() {}" {
+		t.Fatalf("unexpected first block: %+v", blocks[0])
+	}
+	if blocks[1].Lang != "" || blocks[1].Code != "ls -la" {
+		t.Fatalf("unexpected second block: %+v", blocks[1])
+	}
+	if blocks[0].FirstLine() != "package main" {
+		t.Fatalf("unexpected first line: %q", blocks[0].FirstLine())
+	}
+}
+
+func TestExtractCodeBlocksNestedFence(t *testing.T) {
+	md := "````markdown\n```sh\necho hi\n```\n````"
+	blocks := extractCodeBlocks(md)
+	if len(blocks) != 1 {
+		t.Fatalf("expected 1 block, got %d", len(blocks))
+	}
+	if blocks[0].Code != "```sh\necho hi\n```" {
+		t.Fatalf("inner fence should be kept verbatim, got %q", blocks[0].Code)
+	}
+}
+
+func TestExtractCodeBlocksUnterminated(t *testing.T) {
+	blocks := extractCodeBlocks("```python\nprint(1)\n")
+	if len(blocks) != 1 || blocks[0].Lang != "python" || blocks[0].Code != "print(1)\n" {
+		t.Fatalf("unexpected blocks: %+v", blocks)
+	}
+}
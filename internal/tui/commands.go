@@ -0,0 +1,319 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"miniopencode/internal/clipboard"
+	"miniopencode/internal/export"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// noticeMsg replaces the notice shown in the status bar.
+type noticeMsg string
+
+const (
+	copyUsage   = "usage: /copy [N] [code K]"
+	blocksUsage = "usage: /blocks [N]"
+	saveUsage   = "usage: /save K PATH"
+	exportUsage = "usage: /export md|json|html [PATH]"
+)
+
+const exportTimeout = 30 * time.Second
+
+// pendingSave is a code block waiting for confirmation to overwrite path.
+type pendingSave struct {
+	path string
+	code string
+}
+
+// commandNames are the verbs handled locally. Other input starting with "/",
+// such as a path, is sent as a prompt.
+var commandNames = map[string]bool{"copy": true, "blocks": true, "save": true, "export": true}
+
+// isCommand reports whether text starts with "/" and a known command verb.
+func isCommand(text string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	name, ok := strings.CutPrefix(fields[0], "/")
+	return ok && commandNames[name]
+}
+
+// unescapePrompt drops the first slash of input starting with "//", so that
+// "//copy" sends "/copy" as a prompt.
+func unescapePrompt(text string) string {
+	trimmed := strings.TrimLeft(text, " \t")
+	if strings.HasPrefix(trimmed, "//") {
+		return trimmed[1:]
+	}
+	return text
+}
+
+// runCommand handles input lines starting with a command verb locally instead
+// of sending them to the server.
+func (m Model) runCommand(line string) (Model, tea.Cmd) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	if len(fields) == 0 {
+		return m, nil
+	}
+	switch fields[0] {
+	case "copy":
+		return m.copyCommand(fields[1:])
+	case "blocks":
+		return m.blocksCommand(fields[1:])
+	case "save":
+		return m.saveCommand(fields[1:])
+	case "export":
+		return m.exportCommand(fields[1:])
+	default:
+		m.notice = "unknown command: /" + fields[0]
+		return m, nil
+	}
+}
+
+// copyCommand copies the raw markdown of an answer, or one of its fenced code
+// blocks, to the clipboard. N counts answers back from the latest (1), K
+// counts code blocks from the top of the answer (1).
+func (m Model) copyCommand(args []string) (Model, tea.Cmd) {
+	n := 1
+	if len(args) > 0 && args[0] != "code" {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 1 {
+			m.notice = copyUsage
+			return m, nil
+		}
+		n = v
+		args = args[1:]
+	}
+
+	m.flushTypewriterBuf()
+	answer, ok := m.transcript.Answer(n)
+	if !ok {
+		m.notice = fmt.Sprintf("no answer #%d to copy", n)
+		return m, nil
+	}
+	if len(args) == 0 {
+		return m, copyToClipboard(answer, "answer")
+	}
+
+	if len(args) != 2 || args[0] != "code" {
+		m.notice = copyUsage
+		return m, nil
+	}
+	k, err := strconv.Atoi(args[1])
+	if err != nil {
+		m.notice = copyUsage
+		return m, nil
+	}
+	blocks := extractCodeBlocks(answer)
+	if k < 1 || k > len(blocks) {
+		m.notice = fmt.Sprintf("answer #%d has %d code block(s)", n, len(blocks))
+		return m, nil
+	}
+	return m, copyToClipboard(blocks[k-1].Code, fmt.Sprintf("code block %d", k))
+}
+
+func copyToClipboard(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.Copy(os.Stderr, text); err != nil {
+			return noticeMsg("copy failed: " + err.Error())
+		}
+		return noticeMsg(fmt.Sprintf("copied %s (%d bytes)", what, len(text)))
+	}
+}
+
+// listedBlocks are the code blocks last shown by /blocks. /save picks from
+// this snapshot, so answers arriving in between do not change what K means.
+type listedBlocks struct {
+	answer int
+	blocks []CodeBlock
+}
+
+// blocksCommand lists the fenced code blocks of the N-th most recent answer
+// and makes them the blocks /save picks from.
+func (m Model) blocksCommand(args []string) (Model, tea.Cmd) {
+	n := 1
+	if len(args) > 1 {
+		m.notice = blocksUsage
+		return m, nil
+	}
+	if len(args) == 1 {
+		v, err := strconv.Atoi(args[0])
+		if err != nil || v < 1 {
+			m.notice = blocksUsage
+			return m, nil
+		}
+		n = v
+	}
+
+	m.flushTypewriterBuf()
+	answer, ok := m.transcript.Answer(n)
+	if !ok {
+		m.notice = fmt.Sprintf("no answer #%d", n)
+		return m, nil
+	}
+	blocks := extractCodeBlocks(answer)
+	m.listed = &listedBlocks{answer: n, blocks: blocks}
+	if len(blocks) == 0 {
+		m.notice = fmt.Sprintf("answer #%d has no code blocks", n)
+		return m, nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Code blocks in answer #%d (save with /save K PATH):\n", n)
+	for i, block := range blocks {
+		lang := block.Lang
+		if lang == "" {
+			lang = "text"
+		}
+		fmt.Fprintf(&b, "\n%d. **%s** `%s`", i+1, lang, block.FirstLine())
+	}
+	m.transcript.AddAssistantSystemLine(b.String())
+	m.viewport.SetContent(m.transcript.Render(m.showThinking, m.showTools, m.spinner.View(), m.sending))
+	if m.followOutput {
+		m.viewport.GotoBottom()
+	}
+	return m, nil
+}
+
+// saveCommand writes code block K of those last listed by /blocks (of the
+// latest answer by default) to PATH. An existing file with different content
+// is only overwritten after the user confirms the shown diff.
+func (m Model) saveCommand(args []string) (Model, tea.Cmd) {
+	if len(args) < 2 {
+		m.notice = saveUsage
+		return m, nil
+	}
+	k, err := strconv.Atoi(args[0])
+	if err != nil {
+		m.notice = saveUsage
+		return m, nil
+	}
+	n, blocks := 1, []CodeBlock(nil)
+	if m.listed != nil {
+		n, blocks = m.listed.answer, m.listed.blocks
+	} else {
+		m.flushTypewriterBuf()
+		answer, ok := m.transcript.Answer(n)
+		if !ok {
+			m.notice = fmt.Sprintf("no answer #%d", n)
+			return m, nil
+		}
+		blocks = extractCodeBlocks(answer)
+	}
+	if k < 1 || k > len(blocks) {
+		m.notice = fmt.Sprintf("answer #%d has %d code block(s)", n, len(blocks))
+		return m, nil
+	}
+	code := blocks[k-1].Code
+	if !strings.HasSuffix(code, "\n") {
+		code += "\n"
+	}
+	path := expandHome(strings.Join(args[1:], " "))
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return m, writeCodeBlock(path, code)
+	case err != nil:
+		m.notice = "save failed: " + err.Error()
+		return m, nil
+	case string(existing) == code:
+		m.notice = path + " is already up to date"
+		return m, nil
+	}
+
+	diff := unifiedDiff(path, fmt.Sprintf("%s (block %d)", path, k), string(existing), code)
+	m.transcript.AddAssistantSystemLine(fmt.Sprintf("%s already exists:\n\n```diff\n%s```\n\nOverwrite? [y/N]", path, diff))
+	m.viewport.SetContent(m.transcript.Render(m.showThinking, m.showTools, m.spinner.View(), m.sending))
+	if m.followOutput {
+		m.viewport.GotoBottom()
+	}
+	m.pendingSave = &pendingSave{path: path, code: code}
+	m.notice = "overwrite " + path + "? [y/N]"
+	return m, nil
+}
+
+// confirmSave consumes the answer to an overwrite prompt.
+func (m Model) confirmSave(answer string) (Model, tea.Cmd) {
+	save := m.pendingSave
+	m.pendingSave = nil
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return m, writeCodeBlock(save.path, save.code)
+	default:
+		m.notice = "save cancelled"
+		return m, nil
+	}
+}
+
+func writeCodeBlock(path, code string) tea.Cmd {
+	return func() tea.Msg {
+		if dir := filepath.Dir(path); dir != "" {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return noticeMsg("save failed: " + err.Error())
+			}
+		}
+		if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+			return noticeMsg("save failed: " + err.Error())
+		}
+		return noticeMsg(fmt.Sprintf("saved %s (%d bytes)", path, len(code)))
+	}
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// exportCommand writes the current session's full history, fetched from the
+// server, to PATH (default: <session id>.<format> in the working directory).
+func (m Model) exportCommand(args []string) (Model, tea.Cmd) {
+	if len(args) < 1 {
+		m.notice = exportUsage
+		return m, nil
+	}
+	format, err := export.ParseFormat(args[0])
+	if err != nil {
+		m.notice = exportUsage
+		return m, nil
+	}
+	if m.streamer == nil || m.sessionID == "" {
+		m.notice = "export needs a server session"
+		return m, nil
+	}
+	path := m.sessionID + format.Ext()
+	if len(args) > 1 {
+		path = expandHome(strings.Join(args[1:], " "))
+	}
+
+	cli, sessionID := m.streamer.Client, m.sessionID
+	m.notice = "exporting to " + path + "..."
+	return m, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		doc, err := export.Load(ctx, cli, sessionID)
+		if err != nil {
+			return noticeMsg("export failed: " + err.Error())
+		}
+		if err := export.WriteFile(path, doc, format); err != nil {
+			return noticeMsg("export failed: " + err.Error())
+		}
+		return noticeMsg(fmt.Sprintf("exported %d messages to %s", len(doc.Messages), path))
+	}
+}
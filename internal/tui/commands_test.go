@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func modelWithAnswers(answers ...string) Model {
+	m := NewModel(DefaultUIConfig())
+	m.width = 80
+	m.height = 24
+	m.applySizes()
+	for i, a := range answers {
+		m.transcript.AddUserMessage("question")
+		m.transcript.AppendAssistantChunk("msg-"+string(rune('a'+i)), "part-1", ChunkAnswer, a)
+	}
+	return m
+}
+
+func TestCopyCommandSelectsCodeBlock(t *testing.T) {
+	m := modelWithAnswers("first", "Run:\n```sh\necho hi\n```")
+
+	m, cmd := m.runCommand("/copy code 1")
+	if cmd == nil {
+		t.Fatalf("expected copy command, notice=%q", m.notice)
+	}
+
+	m, cmd = m.runCommand("/copy code 2")
+	if cmd != nil || !strings.Contains(m.notice, "1 code block") {
+		t.Fatalf("expected out-of-range notice, got %q", m.notice)
+	}
+}
+
+func TestCopyCommandMissingAnswer(t *testing.T) {
+	m := modelWithAnswers("only")
+
+	m, cmd := m.runCommand("/copy 2")
+	if cmd != nil || m.notice != "no answer #2 to copy" {
+		t.Fatalf("unexpected result: cmd=%v notice=%q", cmd != nil, m.notice)
+	}
+}
+
+func TestUnknownCommandSetsNotice(t *testing.T) {
+	m := modelWithAnswers()
+
+	m, _ = m.runCommand("/nope")
+	if m.notice != "unknown command: /nope" {
+		t.Fatalf("unexpected notice: %q", m.notice)
+	}
+}
+
+func TestCommandIsNotSent(t *testing.T) {
+	m := modelWithAnswers("answer")
+	m.textinput.SetValue("/copy")
+
+	m, _ = m.sendInput()
+	if m.sending {
+		t.Fatalf("commands must not be sent to the server")
+	}
+	if m.textinput.Value() != "" {
+		t.Fatalf("expected input cleared, got %q", m.textinput.Value())
+	}
+}
+
+func TestOtherSlashInputIsSent(t *testing.T) {
+	for _, tc := range []struct{ input, sent string }{
+		{"/etc/nginx.conf fails to parse, why?", "/etc/nginx.conf fails to parse, why?"},
+		{"/nope", "/nope"},
+		{"//copy that", "/copy that"},
+	} {
+		m := modelWithAnswers()
+		m.textinput.SetValue(tc.input)
+
+		m, _ = m.sendInput()
+		if !m.sending {
+			t.Fatalf("%q must be sent as a prompt, notice=%q", tc.input, m.notice)
+		}
+		msgs := m.transcript.messages
+		if len(msgs) < 2 || msgs[len(msgs)-2].Role != RoleUser || msgs[len(msgs)-2].Parts[0].Text.String() != tc.sent {
+			t.Fatalf("%q: expected prompt %q in transcript", tc.input, tc.sent)
+		}
+	}
+}
+
+func TestBlocksCommandListsBlocks(t *testing.T) {
+	m := modelWithAnswers("```go\npackage main\n```\n\n```\nplain\n```")
+
+	m, _ = m.runCommand("/blocks")
+	if m.listed == nil || m.listed.answer != 1 || len(m.listed.blocks) != 2 {
+		t.Fatalf("expected the blocks of answer 1 to be listed, got %+v", m.listed)
+	}
+	if _, ok := m.transcript.Answer(1); !ok {
+		t.Fatalf("listing must not hide the answer")
+	}
+	rendered := m.transcript.Render(true, true, "", false)
+	if !strings.Contains(rendered, "package main") || !strings.Contains(rendered, "plain") {
+		t.Fatalf("expected listing with first lines, got %q", rendered)
+	}
+}
+
+func TestSaveCommandWritesNewFile(t *testing.T) {
+	m := modelWithAnswers("```sh\necho hi\n```")
+	path := filepath.Join(t.TempDir(), "sub", "hi.sh")
+
+	m, cmd := m.runCommand("/save 1 " + path)
+	if cmd == nil {
+		t.Fatalf("expected write command, notice=%q", m.notice)
+	}
+	cmd()
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "echo hi\n" {
+		t.Fatalf("unexpected file content %q err=%v", data, err)
+	}
+}
+
+func TestSaveCommandUsesListedBlocks(t *testing.T) {
+	m := modelWithAnswers("```sh\necho listed\n```")
+	m, _ = m.runCommand("/blocks")
+
+	// A new answer arrives before /save.
+	m.transcript.AddUserMessage("question")
+	m.transcript.AppendAssistantChunk("msg-z", "part-1", ChunkAnswer, "```sh\necho newer\n```")
+
+	path := filepath.Join(t.TempDir(), "listed.sh")
+	m, cmd := m.runCommand("/save 1 " + path)
+	if cmd == nil {
+		t.Fatalf("expected write command, notice=%q", m.notice)
+	}
+	cmd()
+	if data, _ := os.ReadFile(path); string(data) != "echo listed\n" {
+		t.Fatalf("expected the listed block to be saved, got %q", data)
+	}
+}
+
+func TestSaveCommandAsksBeforeOverwrite(t *testing.T) {
+	m := modelWithAnswers("```sh\necho new\n```")
+	path := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(path, []byte("echo old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, cmd := m.runCommand("/save 1 " + path)
+	if cmd != nil || m.pendingSave == nil {
+		t.Fatalf("expected confirmation prompt, notice=%q", m.notice)
+	}
+
+	m.textinput.SetValue("n")
+	m, cmd = m.sendInput()
+	if cmd != nil || m.pendingSave != nil || m.notice != "save cancelled" {
+		t.Fatalf("expected cancel, notice=%q", m.notice)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "echo old\n" {
+		t.Fatalf("file must be untouched after cancel, got %q", data)
+	}
+
+	m, _ = m.runCommand("/save 1 " + path)
+	m.textinput.SetValue("y")
+	m, cmd = m.sendInput()
+	if cmd == nil {
+		t.Fatalf("expected write after confirmation")
+	}
+	cmd()
+	if data, _ := os.ReadFile(path); string(data) != "echo new\n" {
+		t.Fatalf("expected overwritten file, got %q", data)
+	}
+}
@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffIdentical(t *testing.T) {
+	if got := unifiedDiff("a", "b", "x\ny\n", "x\ny\n"); got != "" {
+		t.Fatalf("expected empty diff, got %q", got)
+	}
+}
+
+func TestUnifiedDiffSingleChange(t *testing.T) {
+	oldText := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	newText := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n"
+
+	got := unifiedDiff("old", "new", oldText, newText)
+	want := "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n"
+	if got != want {
+		t.Fatalf("unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffSplitsDistantHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 0; i < 30; i++ {
+		line := string(rune('a' + i%26))
+		oldLines = append(oldLines, line)
+		newLines = append(newLines, line)
+	}
+	newLines[2] = "changed-early"
+	newLines[25] = "changed-late"
+
+	got := unifiedDiff("old", "new", strings.Join(oldLines, "\n"), strings.Join(newLines, "\n"))
+	if strings.Count(got, "@@ -") != 2 {
+		t.Fatalf("expected two hunks, got:\n%s", got)
+	}
+}
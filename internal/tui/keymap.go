@@ -1,6 +1,10 @@
 package tui
 
-import "github.com/charmbracelet/bubbles/key"
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
 
 type KeyMap struct {
 	Quit       key.Binding
@@ -35,3 +39,33 @@ func DefaultKeyMap() KeyMap {
 		Bottom:     key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "bottom")),
 	}
 }
+
+// KeyMapFromConfig returns the default key map with the bindings named in
+// keys (config action name to keys) replaced. Unknown actions are ignored;
+// config validation reports them.
+func KeyMapFromConfig(keys map[string][]string) KeyMap {
+	km := DefaultKeyMap()
+	actions := map[string]*key.Binding{
+		"quit":        &km.Quit,
+		"help":        &km.Help,
+		"send":        &km.SendSingle,
+		"resize_up":   &km.ResizeUp,
+		"resize_down": &km.ResizeDown,
+		"up":          &km.Up,
+		"down":        &km.Down,
+		"page_up":     &km.PageUp,
+		"page_down":   &km.PageDown,
+		"half_up":     &km.HalfUp,
+		"half_down":   &km.HalfDown,
+		"top":         &km.Top,
+		"bottom":      &km.Bottom,
+	}
+	for action, k := range keys {
+		b, ok := actions[action]
+		if !ok || len(k) == 0 {
+			continue
+		}
+		*b = key.NewBinding(key.WithKeys(k...), key.WithHelp(strings.Join(k, "/"), b.Help().Desc))
+	}
+	return km
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"miniopencode/internal/config"
 )
 
 type UIMode int
@@ -60,6 +62,7 @@ type Model struct {
 	textinput   textinput.Model
 	spinner     spinner.Model
 	placeholder string
+	notice      string
 
 	mode          UIMode
 	width         int
@@ -81,11 +84,18 @@ type Model struct {
 
 	transcript *Transcript
 
+	listed      *listedBlocks
+	pendingSave *pendingSave
+
 	lastPartID    string
 	lastMessageID string
 
-	serverHost string
-	serverPort int
+	// server names the server in the status bar (see serverLabel).
+	server string
+
+	// cfg is the config in effect; watcher, if set, reloads it on change.
+	cfg     config.Config
+	watcher *configWatcher
 
 	tw *typewriter
 }
@@ -162,6 +172,9 @@ func (m Model) Init() tea.Cmd {
 	if m.errCh != nil {
 		cmds = append(cmds, waitForError(m.errCh))
 	}
+	if m.watcher != nil {
+		cmds = append(cmds, m.watchConfig())
+	}
 	return tea.Batch(cmds...)
 }
 
@@ -215,6 +228,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleTypewriterTick()
 	case sendComplete:
 		m = m.handleSendComplete()
+	case noticeMsg:
+		m.notice = string(msg)
+		return m, nil
+	case configTickMsg:
+		return m, m.watchConfig()
+	case configReloadMsg:
+		if msg.err != nil {
+			m.notice = "config not reloaded: " + reloadError(msg.err)
+		} else {
+			m = m.applyConfig(msg.cfg)
+		}
+		return m, m.watchConfig()
 	case error:
 		m = m.clearInput()
 		m.sending = false
@@ -333,10 +358,14 @@ func (m Model) renderStatus() string {
 	if m.sending {
 		sendingIndicator = fmt.Sprintf(" %s thinking...", m.spinner.View())
 	}
+	noticeIndicator := ""
+	if m.notice != "" {
+		noticeIndicator = " | " + m.notice
+	}
 
 	left := titleStyle.Render(fmt.Sprintf("miniopencode"))
-	middle := statusStyle.Render(fmt.Sprintf("session=%s | mode=%s%s%s", m.sessionID, mode, multilineIndicator, sendingIndicator))
-	right := statusStyle.Render(fmt.Sprintf("%s:%d", m.serverHost, m.serverPort))
+	middle := statusStyle.Render(fmt.Sprintf("session=%s | mode=%s%s%s%s", m.sessionID, mode, multilineIndicator, sendingIndicator, noticeIndicator))
+	right := statusStyle.Render(m.server)
 
 	gap := m.width - lipgloss.Width(left) - lipgloss.Width(middle) - lipgloss.Width(right)
 	if gap < 0 {
@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"miniopencode/internal/config"
+)
+
+const configPollInterval = time.Second
+
+// configTickMsg asks the model to schedule the next config check.
+type configTickMsg struct{}
+
+// configReloadMsg carries a config reloaded after its file changed.
+type configReloadMsg struct {
+	cfg config.Config
+	err error
+}
+
+// configWatcher polls the loaded config files (the user config and any
+// project overlay) and reloads the config, with the same options, when the
+// size or modification time of one of them changes.
+type configWatcher struct {
+	path     string
+	opts     config.Options
+	interval time.Duration
+	files    []watchedFile
+}
+
+type watchedFile struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+func newConfigWatcher(path string, opts config.Options, extra ...string) *configWatcher {
+	w := &configWatcher{path: path, opts: opts, interval: configPollInterval}
+	for _, p := range append([]string{path}, extra...) {
+		f := watchedFile{path: p}
+		if info, err := os.Stat(p); err == nil {
+			f.modTime, f.size = info.ModTime(), info.Size()
+		}
+		w.files = append(w.files, f)
+	}
+	return w
+}
+
+// changed reports whether any file differs from the last time it was seen.
+// A missing file counts as unchanged.
+func (w *configWatcher) changed() bool {
+	changed := false
+	for i := range w.files {
+		f := &w.files[i]
+		info, err := os.Stat(f.path)
+		if err != nil || (info.ModTime().Equal(f.modTime) && info.Size() == f.size) {
+			continue
+		}
+		f.modTime, f.size = info.ModTime(), info.Size()
+		changed = true
+	}
+	return changed
+}
+
+func (w *configWatcher) watch() tea.Cmd {
+	return tea.Tick(w.interval, func(time.Time) tea.Msg {
+		if !w.changed() {
+			return configTickMsg{}
+		}
+		cfg, err := config.Load(w.path, w.opts)
+		return configReloadMsg{cfg: cfg, err: err}
+	})
+}
+
+// watchConfig schedules the next config check, if the config is watched.
+func (m Model) watchConfig() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	return m.watcher.watch()
+}
+
+// reloadError condenses a load error to one status-bar line.
+func reloadError(err error) string {
+	var verr *config.ValidationError
+	if errors.As(err, &verr) && len(verr.Issues) > 0 {
+		return verr.Issues[0].Error()
+	}
+	return strings.SplitN(err.Error(), "\n", 2)[0]
+}
+
+// applyConfig re-applies the settings that can change while running (theme,
+// UI toggles, prompt defaults, keys) and reports in the status bar those
+// that only take effect after a restart.
+func (m Model) applyConfig(cfg config.Config) Model {
+	var restart []string
+	if !reflect.DeepEqual(m.cfg.Server, cfg.Server) {
+		restart = append(restart, "server")
+	}
+	if !reflect.DeepEqual(m.cfg.Session, cfg.Session) {
+		restart = append(restart, "session")
+	}
+	if m.cfg.UI.Mode != cfg.UI.Mode {
+		restart = append(restart, "ui.mode")
+	}
+
+	applyTheme(cfg.Theme)
+	m.keys = KeyMapFromConfig(cfg.Keys)
+	m.showThinking = cfg.UI.ShowThinking
+	m.showTools = cfg.UI.ShowTools
+	m.maxOutputLines = cfg.UI.MaxOutputLines
+	m.promptCfg = PromptConfig{Agent: cfg.Defaults.Agent, ProviderID: cfg.Defaults.ProviderID, ModelID: cfg.Defaults.ModelID}
+	if cfg.UI.InputHeight != m.cfg.UI.InputHeight {
+		m.inputHeight = cfg.UI.InputHeight
+	}
+	// Keep the settings that were not applied so they are reported again
+	// until the restart.
+	cfg.Server, cfg.Session, cfg.UI.Mode = m.cfg.Server, m.cfg.Session, m.cfg.UI.Mode
+	m.cfg = cfg
+
+	m.applySizes()
+	if m.transcript.Len() > 0 {
+		m.viewport.SetContent(m.transcript.Render(m.showThinking, m.showTools, m.spinner.View(), m.sending))
+	}
+	m.notice = "config reloaded"
+	if len(restart) > 0 {
+		m.notice += "; restart to apply " + strings.Join(restart, ", ")
+	}
+	return m
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"miniopencode/internal/config"
+)
+
+func TestConfigWatcherDetectsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "miniopencode.yaml")
+	if err := os.WriteFile(path, []byte("ui:\n  show_tools: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := newConfigWatcher(path, config.Options{})
+	if w.changed() {
+		t.Fatal("unchanged file reported as changed")
+	}
+	if err := os.WriteFile(path, []byte("ui:\n  show_tools: false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed() {
+		t.Fatal("rewritten file not detected")
+	}
+	if w.changed() {
+		t.Fatal("change should be reported once")
+	}
+
+	w.interval = time.Millisecond
+	os.Chtimes(path, time.Now().Add(time.Hour), time.Now().Add(time.Hour))
+	msg, ok := w.watch()().(configReloadMsg)
+	if !ok || msg.err != nil || msg.cfg.UI.ShowTools {
+		t.Fatalf("expected reloaded config, got %+v", msg)
+	}
+}
+
+func TestApplyConfigUpdatesRunningModel(t *testing.T) {
+	t.Cleanup(func() { applyTheme(config.Default().Theme) })
+
+	old := config.Default()
+	m := NewModel(DefaultUIConfig())
+	m.cfg = old
+	m.width, m.height = 100, 30
+	m.ready = true
+
+	next := config.Default()
+	next.UI.ShowThinking = false
+	next.Defaults.ModelID = "big-model"
+	next.Theme.StatusColor = "#ff0000"
+	next.Keys = map[string][]string{"quit": {"ctrl+q"}}
+	next.Server.Port = 5000
+
+	updated, _ := m.Update(configReloadMsg{cfg: next})
+	m = updated.(Model)
+	if m.showThinking || m.promptCfg.ModelID != "big-model" {
+		t.Fatalf("settings not applied: thinking=%v prompt=%+v", m.showThinking, m.promptCfg)
+	}
+	if !key.Matches(tea.KeyMsg{Type: tea.KeyCtrlQ}, m.keys.Quit) {
+		t.Fatal("quit should be rebound to ctrl+q")
+	}
+	if statusStyle.GetForeground() != lipgloss.Color("#ff0000") {
+		t.Fatalf("theme not applied: %v", statusStyle.GetForeground())
+	}
+	if !strings.Contains(m.notice, "restart to apply server") {
+		t.Fatalf("server change should ask for a restart: %q", m.notice)
+	}
+	if m.cfg.Server.Port != old.Server.Port {
+		t.Fatal("server settings must stay as running until restart")
+	}
+
+	bad := next
+	updated, _ = m.Update(configReloadMsg{cfg: bad, err: &config.ValidationError{Issues: []config.Issue{{File: "c.yaml", Line: 3, Key: "ui.mode", Message: "bad"}}}})
+	if notice := updated.(Model).notice; notice != "config not reloaded: c.yaml:3: ui.mode: bad" {
+		t.Fatalf("unexpected notice: %q", notice)
+	}
+}
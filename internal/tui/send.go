@@ -14,11 +14,23 @@ func (m Model) sendInput() (Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.pendingSave != nil {
+		m = m.clearInput()
+		return m.confirmSave(text)
+	}
+
+	if isCommand(text) {
+		m = m.clearInput()
+		return m.runCommand(text)
+	}
+
 	if m.sending {
 		return m, nil
 	}
+	text = unescapePrompt(text)
 
 	m.sending = true
+	m.notice = ""
 	m.transcript.AddUserMessage(text)
 	m.transcript.EnsureAssistantMessage("")
 	m.viewport.SetContent(m.transcript.Render(m.showThinking, m.showTools, m.spinner.View(), m.sending))
@@ -8,10 +8,13 @@ import (
 	"sync"
 
 	"miniopencode/internal/client"
+	"miniopencode/internal/session"
 )
 
 type Streamer struct {
 	Client *client.Client
+	// Usage, if set, is kept current from message.updated events.
+	Usage  *session.UsageCache
 	Events chan Chunk
 	Errors chan error
 
@@ -75,6 +78,7 @@ func (s *Streamer) parseSSEToChunk(ev client.SSEEvent) Chunk {
 	switch e := parsed.(type) {
 	case *client.MessageUpdatedEvent:
 		info := e.Properties.Info
+		s.Usage.Observe(info)
 		s.mu.Lock()
 		s.messageRoles[info.ID] = info.Role
 		s.mu.Unlock()
@@ -1,6 +1,10 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"miniopencode/internal/config"
+)
 
 var (
 	outputBorderStyle = lipgloss.NewStyle().
@@ -41,3 +45,27 @@ func renderWithBorder(content string, style lipgloss.Style, width, height int) s
 	contentHeight := max(0, height-2)
 	return style.Width(contentWidth).Height(contentHeight).Render(content)
 }
+
+var borders = map[string]lipgloss.Border{
+	"rounded": lipgloss.RoundedBorder(),
+	"normal":  lipgloss.NormalBorder(),
+	"thick":   lipgloss.ThickBorder(),
+	"double":  lipgloss.DoubleBorder(),
+	"hidden":  lipgloss.HiddenBorder(),
+}
+
+// applyTheme restyles the UI from the theme config. Unknown border styles
+// fall back to rounded.
+func applyTheme(t config.ThemeConfig) {
+	border, ok := borders[t.BorderStyle]
+	if !ok {
+		border = lipgloss.RoundedBorder()
+	}
+	outputBorderStyle = outputBorderStyle.Border(border).BorderForeground(lipgloss.Color(t.OutputBorderColor))
+	inputBorderStyle = inputBorderStyle.Border(border).BorderForeground(lipgloss.Color(t.InputBorderColor))
+	statusStyle = statusStyle.Foreground(lipgloss.Color(t.StatusColor))
+	helpStyle = helpStyle.Foreground(lipgloss.Color(t.StatusColor))
+	thinkingStyle = thinkingStyle.Foreground(lipgloss.Color(t.ThinkingColor))
+	toolStyle = toolStyle.Foreground(lipgloss.Color(t.ToolColor))
+	answerStyle = answerStyle.Foreground(lipgloss.Color(t.AnswerColor))
+}
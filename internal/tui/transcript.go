@@ -26,6 +26,7 @@ type TranscriptMessage struct {
 	Role    Role
 	Created time.Time
 	Pending bool
+	System  bool // local notices, never part of the conversation
 	Parts   []TranscriptPart
 }
 
@@ -34,6 +35,13 @@ type Transcript struct {
 	messages []TranscriptMessage
 }
 
+// Len returns the number of messages.
+func (t *Transcript) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.messages)
+}
+
 func (t *Transcript) AddUserMessage(text string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -47,7 +55,7 @@ func (t *Transcript) AddUserMessage(text string) {
 }
 
 func (t *Transcript) EnsurePendingAssistant(messageID string) {
-	if len(t.messages) == 0 || t.messages[len(t.messages)-1].Role != RoleAssistant {
+	if len(t.messages) == 0 || t.messages[len(t.messages)-1].Role != RoleAssistant || t.messages[len(t.messages)-1].System {
 		t.messages = append(t.messages, TranscriptMessage{
 			ID:      messageID,
 			Role:    RoleAssistant,
@@ -148,12 +156,41 @@ func chunkKindToPartKind(ck ChunkKind) client.PartKind {
 func (t *Transcript) AddAssistantSystemLine(text string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	t.messages = append(t.messages, TranscriptMessage{Role: RoleAssistant, Created: time.Now()})
+	t.messages = append(t.messages, TranscriptMessage{Role: RoleAssistant, Created: time.Now(), System: true})
 	msg := &t.messages[len(t.messages)-1]
 	msg.Parts = append(msg.Parts, TranscriptPart{Kind: ChunkAnswer})
 	msg.Parts[len(msg.Parts)-1].Text.WriteString(text)
 }
 
+// Answer returns the raw markdown of the n-th most recent assistant answer
+// (n=1 is the latest), joining its answer parts and skipping thinking, tool
+// output and local notices.
+func (t *Transcript) Answer(n int) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if n < 1 {
+		return "", false
+	}
+	for i := len(t.messages) - 1; i >= 0; i-- {
+		m := t.messages[i]
+		if m.Role != RoleAssistant || m.System || m.Pending {
+			continue
+		}
+		n--
+		if n > 0 {
+			continue
+		}
+		var texts []string
+		for _, p := range m.Parts {
+			if p.Kind == ChunkAnswer {
+				texts = append(texts, p.Text.String())
+			}
+		}
+		return strings.Join(texts, "\n\n"), true
+	}
+	return "", false
+}
+
 func (t *Transcript) Render(showThinking, showTools bool, spinnerFrame string, showSpinner bool) string {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -185,3 +185,23 @@ func TestTranscript_ApplyUpdate_NoDuplication(t *testing.T) {
 		t.Errorf("expected 'Hello world!', got %q", text)
 	}
 }
+
+func TestTranscript_AnswerSkipsNoticesAndReasoning(t *testing.T) {
+	tr := &Transcript{}
+	tr.AddUserMessage("q1")
+	tr.AppendAssistantChunk("msg-1", "r-1", ChunkThinking, "hmm")
+	tr.AppendAssistantChunk("msg-1", "p-1", ChunkAnswer, "first")
+	tr.AddUserMessage("q2")
+	tr.AppendAssistantChunk("msg-2", "p-2", ChunkAnswer, "second")
+	tr.AddAssistantSystemLine("[Error] boom")
+
+	if got, ok := tr.Answer(1); !ok || got != "second" {
+		t.Fatalf("expected latest answer 'second', got %q ok=%v", got, ok)
+	}
+	if got, ok := tr.Answer(2); !ok || got != "first" {
+		t.Fatalf("expected previous answer 'first', got %q ok=%v", got, ok)
+	}
+	if _, ok := tr.Answer(3); ok {
+		t.Fatalf("expected no third answer")
+	}
+}